@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Armored shares wrap a share in BEGIN and END lines with a few headers,
+// like an OpenPGP message, so they survive being pasted into an email or a
+// ticket and several of them can be kept in one file:
+//
+//	-----BEGIN SHAMIR SHARE-----
+//	Set-ID: DE34-84F9
+//	Index: 2
+//	Threshold: 2
+//	Mode: raw
+//	Holder: Bob
+//
+//	HciEZef0otYtG/dl
+//	=hc5i
+//	-----END SHAMIR SHARE-----
+//
+// The body is the share in base64 and the line starting with "=" its CRC-24.
+// restore requires the Index and Mode headers, refuses shares whose Set-ID
+// headers differ and asks for as many shares as Threshold says; the other
+// headers are informational.
+
+const (
+	shareFormatArmor = "armor"
+
+	armorBegin     = "-----BEGIN SHAMIR SHARE-----"
+	armorEnd       = "-----END SHAMIR SHARE-----"
+	armorLineWidth = 64
+)
+
+// Armor header names.
+const (
+	armorSetID     = "Set-ID"
+	armorIndex     = "Index"
+	armorThreshold = "Threshold"
+	armorMode      = "Mode"
+	armorHolder    = "Holder"
+	armorComment   = "Comment"
+)
+
+// armorEncoding is the shareEncoding of armored blocks. comment goes into
+// the Comment header of shares that have none.
+type armorEncoding struct {
+	comment string
+}
+
+func (armorEncoding) name() string { return shareFormatArmor }
+
+func (armorEncoding) detect(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), armorBegin)
+}
+
+func (a armorEncoding) encode(share shareRecord) (string, error) {
+	if share.Comment == "" {
+		share.Comment = a.comment
+	}
+	return encodeArmoredShare(share), nil
+}
+
+func (a *armorEncoding) setSplitFlags(flags *flag.FlagSet) {
+	flags.StringVar(&a.comment, "comment", "", "comment for the headers of armored shares")
+}
+
+func (a *armorEncoding) checkSplit(opts splitOptions) error {
+	if strings.ContainsAny(a.comment, "\r\n") {
+		return sss.NewError(errUsage, "--comment must not contain line breaks", nil)
+	}
+	return nil
+}
+
+func (*armorEncoding) writeShares(w io.Writer, shares splitShares, opts splitOptions) error {
+	_, err := io.WriteString(w, strings.Join(shares.formatted, "\n"))
+	return err
+}
+
+func (armorEncoding) decode(text string) (shareRecord, error) {
+	blocks, err := decodeArmoredShares(strings.NewReader(text))
+	if err != nil {
+		return shareRecord{}, err
+	}
+	if len(blocks) != 1 {
+		for _, a := range blocks {
+			clear(a.Data)
+		}
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("expected one armored share, found %d", len(blocks)), nil)
+	}
+	return blocks[0], nil
+}
+
+// crc24 is the OpenPGP checksum of RFC 4880, section 6.1.
+func crc24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
+
+func crc24Line(data []byte) string {
+	crc := crc24(data)
+	return "=" + base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)})
+}
+
+// encodeArmoredShare writes a share as an armored block ending in a newline.
+// The Set-ID and Threshold headers are left out when unknown.
+func encodeArmoredShare(a shareRecord) string {
+	var sb strings.Builder
+	sb.WriteString(armorBegin + "\n")
+	if a.SetID != "" {
+		fmt.Fprintf(&sb, "%s: %s\n", armorSetID, a.SetID)
+	}
+	fmt.Fprintf(&sb, "%s: %d\n", armorIndex, a.Index)
+	if a.Threshold > 0 {
+		fmt.Fprintf(&sb, "%s: %d\n", armorThreshold, a.Threshold)
+	}
+	fmt.Fprintf(&sb, "%s: %s\n", armorMode, shareModeName(a.Mode))
+	if a.Holder != "" {
+		fmt.Fprintf(&sb, "%s: %s\n", armorHolder, a.Holder)
+	}
+	if a.Comment != "" {
+		fmt.Fprintf(&sb, "%s: %s\n", armorComment, a.Comment)
+	}
+	sb.WriteString("\n")
+	body := base64.StdEncoding.EncodeToString(a.Data)
+	for len(body) > armorLineWidth {
+		sb.WriteString(body[:armorLineWidth] + "\n")
+		body = body[armorLineWidth:]
+	}
+	sb.WriteString(body + "\n")
+	sb.WriteString(crc24Line(a.Data) + "\n")
+	sb.WriteString(armorEnd + "\n")
+	return sb.String()
+}
+
+// decodeArmoredShares extracts every armored block from text, ignoring
+// anything around them, and checks their CRCs.
+func decodeArmoredShares(r io.Reader) (blocks []shareRecord, err error) {
+	defer func() {
+		if err != nil {
+			for _, a := range blocks {
+				clear(a.Data)
+			}
+		}
+	}()
+	scanner := bufio.NewScanner(r)
+	line := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		line++
+		return strings.TrimSpace(scanner.Text()), true
+	}
+	for {
+		text, ok := next()
+		if !ok {
+			break
+		}
+		if text != armorBegin {
+			continue
+		}
+		start := line
+		malformed := func(msg string) error {
+			return sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("armored share at line %d: %s", start, msg), nil)
+		}
+
+		a := shareRecord{Mode: shareModeRaw}
+		hasMode := false
+		for {
+			text, ok = next()
+			if !ok {
+				return nil, malformed("missing " + armorEnd)
+			}
+			if text == "" {
+				break
+			}
+			key, value, found := strings.Cut(text, ":")
+			if !found {
+				return nil, malformed(fmt.Sprintf("invalid header %q", text))
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case armorSetID:
+				a.SetID = value
+			case armorIndex, armorThreshold:
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 || n > 255 {
+					return nil, malformed(fmt.Sprintf("invalid %s header %q", key, value))
+				}
+				if key == armorIndex {
+					a.Index = n
+				} else {
+					a.Threshold = n
+				}
+			case armorMode:
+				hasMode = true
+				if value != shareModeName(shareModeRaw) {
+					a.Mode = value
+				}
+				if base, _ := splitShareMode(a.Mode); base != shareModeRaw && base != shareModeBIP39 {
+					return nil, malformed(fmt.Sprintf("unknown share mode %q", value))
+				}
+			case armorHolder:
+				a.Holder = value
+			case armorComment:
+				a.Comment = value
+			}
+		}
+		if a.Index == 0 {
+			return nil, malformed("missing " + armorIndex + " header")
+		}
+		if !hasMode {
+			return nil, malformed("missing " + armorMode + " header")
+		}
+
+		var body, checksum strings.Builder
+		for {
+			text, ok = next()
+			if !ok {
+				return nil, malformed("missing " + armorEnd)
+			}
+			if text == armorEnd {
+				break
+			}
+			if strings.HasPrefix(text, "=") {
+				checksum.WriteString(text)
+			} else {
+				body.WriteString(text)
+			}
+		}
+		share, err := base64.StdEncoding.DecodeString(body.String())
+		if err != nil {
+			return nil, malformed("invalid base64 body")
+		}
+		if checksum.Len() == 0 {
+			clear(share)
+			return nil, malformed("missing CRC")
+		}
+		if checksum.String() != crc24Line(share) {
+			clear(share)
+			return nil, malformed("CRC does not match, the share was altered or copied wrongly")
+		}
+		a.Data = share
+		blocks = append(blocks, a)
+	}
+	return blocks, scanner.Err()
+}
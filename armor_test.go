@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC24(t *testing.T) {
+	// The check value of the OpenPGP CRC-24.
+	require.Equal(t, uint32(0x21cf02), crc24([]byte("123456789")))
+	require.Equal(t, uint32(0xb704ce), crc24(nil))
+}
+
+func TestArmoredShareRoundTrip(t *testing.T) {
+	share := make([]byte, 100)
+	for i := range share {
+		share[i] = byte(i * 7)
+	}
+	block := encodeArmoredShare(shareRecord{SetID: "ABCD-EF01", Index: 3, Threshold: 2, Mode: shareModeBIP39 + shareModePadSuffix, Holder: "Alice", Comment: "drill 2026", Data: share})
+	require.True(t, strings.HasPrefix(block, armorBegin+"\n"))
+	require.True(t, strings.HasSuffix(block, armorEnd+"\n"))
+	for _, line := range strings.Split(block, "\n") {
+		require.LessOrEqual(t, len(line), armorLineWidth)
+	}
+
+	// Blocks survive surrounding text, indentation and Windows line endings.
+	text := "Hello,\r\n\r\nhere is my share:\r\n" + strings.ReplaceAll("  "+strings.ReplaceAll(block, "\n", "\n  "), "\n", "\r\n") + "Regards\r\n" + block
+	blocks, err := decodeArmoredShares(strings.NewReader(text))
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	for _, a := range blocks {
+		require.Equal(t, shareRecord{SetID: "ABCD-EF01", Index: 3, Threshold: 2, Mode: "bip39.pad", Holder: "Alice", Comment: "drill 2026", Data: share}, a)
+	}
+
+	blocks, err = decodeArmoredShares(strings.NewReader("no shares here\n"))
+	require.NoError(t, err)
+	require.Empty(t, blocks)
+}
+
+func TestDecodeArmoredShareErrors(t *testing.T) {
+	block := encodeArmoredShare(shareRecord{SetID: "ABCD-EF01", Index: 1, Threshold: 2, Data: []byte("some share bytes")})
+	lines := strings.Split(block, "\n")
+	body := lines[6]
+
+	tests := []struct {
+		name  string
+		block string
+		msg   string
+	}{
+		{"altered body", strings.Replace(block, body, strings.ToUpper(body), 1), "CRC does not match"},
+		{"missing CRC", strings.Replace(block, lines[7]+"\n", "", 1), "missing CRC"},
+		{"truncated", strings.Join(lines[:7], "\n"), "missing " + armorEnd},
+		{"invalid index", strings.Replace(block, "Index: 1", "Index: x", 1), `invalid Index header "x"`},
+		{"missing index", strings.Replace(block, "Index: 1\n", "", 1), "missing Index header"},
+		{"invalid header", strings.Replace(block, "Index: 1", "Index 1", 1), `invalid header "Index 1"`},
+		{"invalid base64", strings.Replace(block, body, body+"!", 1), "invalid base64 body"},
+		{"missing mode", strings.Replace(block, "Mode: raw\n", "", 1), "missing Mode header"},
+		{"unknown mode", strings.Replace(block, "Mode: raw", "Mode: slip39", 1), `unknown share mode "slip39"`},
+		{"unknown padded mode", strings.Replace(block, "Mode: raw", "Mode: hex.pad", 1), `unknown share mode "hex.pad"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeArmoredShares(strings.NewReader("text\n" + tt.block))
+			require.ErrorContains(t, err, "armored share at line 2: "+tt.msg)
+			require.Equal(t, exitMalformedShare, exitCode(err))
+		})
+	}
+}
+
+func TestSplitArmor(t *testing.T) {
+	dir := t.TempDir()
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "armor", "--holders", "Alice,Bob,Carol", "--comment", "test backup", "--type", "bip39", "--compact", testMnemonic, "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	blocks := strings.SplitAfter(out, armorEnd+"\n")
+	require.Len(t, blocks, 4)
+	require.Contains(t, blocks[1], "Holder: Bob\n")
+	require.Contains(t, blocks[1], "Comment: test backup\n")
+	require.Contains(t, blocks[1], "Mode: bip39\n")
+
+	// Shares from one file, from several files, or mixed with typed ones.
+	all := filepath.Join(dir, "all.txt")
+	require.NoError(t, os.WriteFile(all, []byte(out), 0o600))
+	for i, block := range blocks[:3] {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("share-%d.asc", i+1)), []byte("Forwarded share:\n"+block), 0o600))
+	}
+	for _, args := range [][]string{
+		{"--from-file", all},
+		{"--from-file", filepath.Join(dir, "share-3.asc"), "--from-file", filepath.Join(dir, "share-1.asc")},
+	} {
+		out = captureStdout(t, func() {
+			code = runCLI(append([]string{"restore", "--type", "bip39"}, args...))
+		})
+		require.Equal(t, exitOK, code, args)
+		require.Equal(t, testMnemonic+"\n", out)
+	}
+
+	require.Equal(t, exitInsufficientShares, runCLI([]string{"restore", "--from-file", filepath.Join(dir, "share-2.asc")}))
+	empty := filepath.Join(dir, "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte("no shares here\n"), 0o600))
+	require.Equal(t, exitMalformedShare, runCLI([]string{"restore", "--from-file", empty}))
+
+	other := captureStdout(t, func() {
+		runCLI([]string{"split", "--format", "armor", "my_secret", "2", "3"})
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.asc"), []byte(other), 0o600))
+	require.Equal(t, exitInconsistentShares, runCLI([]string{"restore", "--from-file", filepath.Join(dir, "share-1.asc"), "--from-file", filepath.Join(dir, "other.asc")}))
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--comment", "x", "my_secret", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "armor", "--comment", "two\nlines", "my_secret", "2", "3"}))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Base32 shares are meant to be read aloud or typed from paper. They use the
+// Crockford alphabet, which has no I, L, O or U, and are written in groups of
+// four characters, such as "0GAR-DKE4-...". Reading ignores case, spaces and
+// hyphens and takes I and L for 1 and O for 0. Besides the share and its
+// mode and index, a base32 share carries a checksum of its own, and printed
+// lines carry the line checksum of share sheets, so a misheard group is
+// caught on the line where it happened.
+
+const (
+	shareFormatBase32 = "base32"
+
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// base32Encoding is the shareEncoding of base32 shares.
+type base32Encoding struct{}
+
+func (base32Encoding) name() string { return shareFormatBase32 }
+
+func (base32Encoding) printed() {}
+
+func (base32Encoding) writeShares(w io.Writer, shares splitShares, opts splitOptions) error {
+	for i, share := range shares.formatted {
+		fmt.Fprintf(w, "Share %d of %d: %s\n%s\n", i+1, opts.totalShares, share, formatBase32Lines(share))
+	}
+	return nil
+}
+
+// detect accepts any text made of letters, digits and separators, so that
+// a mistyped share gets the base32 error rather than an unknown encoding.
+func (base32Encoding) detect(text string) bool {
+	for _, c := range text {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == ' ' || c == '\t') {
+			return false
+		}
+	}
+	return text != ""
+}
+
+func (base32Encoding) encode(share shareRecord) (string, error) {
+	return encodeBase32Share(share.Index, share.Mode, share.Data)
+}
+
+func (base32Encoding) decode(text string) (shareRecord, error) {
+	index, mode, data, err := decodeBase32Share(text)
+	return shareRecord{Index: index, Mode: mode, Data: data}, err
+}
+
+// encodeBase32Share encodes a share with its index and mode.
+func encodeBase32Share(index int, mode string, share []byte) (string, error) {
+	data, err := packShareRecord(shareRecord{Index: index, Mode: mode, Data: share})
+	if err != nil {
+		return "", fmt.Errorf("base32: %w", err)
+	}
+	defer clear(data)
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	defer clear(values)
+
+	var sb strings.Builder
+	for _, v := range values {
+		if sb.Len() > 0 && (sb.Len()+1)%(sheetGroupSize+1) == 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(crockfordAlphabet[v])
+	}
+	return sb.String(), nil
+}
+
+// normalizeBase32 returns the characters of a base32 share or line in
+// upper case, without separators and with look-alike letters replaced.
+func normalizeBase32(text string) (string, error) {
+	var sb strings.Builder
+	for i, c := range strings.ToUpper(text) {
+		switch c {
+		case '-', ' ', '\t':
+			continue
+		case 'O':
+			c = '0'
+		case 'I', 'L':
+			c = '1'
+		}
+		if c > 127 || strings.IndexByte(crockfordAlphabet, byte(c)) < 0 {
+			return "", sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid base32 character %q at position %d", c, i+1), nil)
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String(), nil
+}
+
+// decodeBase32Share parses a base32 share and checks its checksum.
+func decodeBase32Share(encodedShare string) (index int, mode string, share []byte, err error) {
+	text, err := normalizeBase32(encodedShare)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if len(text)*5%8 >= 5 || len(text)*5/8 < recordHeaderSize+sss.ShareOverhead+1+recordChecksumSize {
+		return 0, "", nil, sss.NewError(sss.ErrMalformedShare, "base32 share has an invalid length, a group may be missing", nil)
+	}
+	values := make([]byte, len(text))
+	for i := 0; i < len(text); i++ {
+		values[i] = byte(strings.IndexByte(crockfordAlphabet, text[i]))
+	}
+	data, err := convertBits(values, 5, 8, false)
+	clear(values)
+	if err != nil {
+		return 0, "", nil, sss.NewError(sss.ErrMalformedShare, "base32 share checksum is invalid, check the share for typos", nil)
+	}
+	defer clear(data)
+	record, err := unpackShareRecord(data, shareFormatBase32)
+	return record.Index, record.Mode, record.Data, err
+}
+
+// formatBase32Lines lays out a base32 share as numbered lines of groups, each
+// followed by its line checksum, for reading aloud or copying by hand.
+func formatBase32Lines(encodedShare string) string {
+	_, lines := sheetLines(encodedShare)
+	var sb strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "  %02d  %-*s  %s\n", line.Number, sheetGroupsPerLine*(sheetGroupSize+1)-1, strings.Join(line.Groups, " "), strings.ToUpper(line.Checksum))
+	}
+	return sb.String()
+}
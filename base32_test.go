@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase32ShareRoundTrip(t *testing.T) {
+	for _, mode := range recordModes {
+		share := []byte{0x00, 0x01, 0x7f, 0x80, 0xfe, 0xff, 0x42}
+		encoded, err := encodeBase32Share(7, mode, share)
+		require.NoError(t, err)
+		require.Regexp(t, `^[0-9A-HJKMNP-TV-Z]{4}(-[0-9A-HJKMNP-TV-Z]{1,4})+$`, encoded)
+
+		// Case, spaces and look-alike letters do not matter when reading.
+		typed := strings.ToLower(strings.ReplaceAll(encoded, "-", " "))
+		typed = strings.NewReplacer("0", "o", "1", "l").Replace(typed)
+		for _, input := range []string{encoded, typed} {
+			index, decodedMode, decoded, err := decodeBase32Share(input)
+			require.NoError(t, err)
+			require.Equal(t, 7, index)
+			require.Equal(t, mode, decodedMode)
+			require.Equal(t, share, decoded)
+		}
+	}
+
+	_, err := encodeBase32Share(1, "unknown", []byte{1, 2})
+	require.Error(t, err)
+	_, err = encodeBase32Share(256, shareModeRaw, []byte{1, 2})
+	require.Error(t, err)
+}
+
+func TestDecodeBase32ShareErrors(t *testing.T) {
+	encoded, err := encodeBase32Share(1, shareModeRaw, []byte("some share bytes"))
+	require.NoError(t, err)
+
+	// Every single wrong character is caught.
+	text := strings.ReplaceAll(encoded, "-", "")
+	for i := range text {
+		c := (strings.IndexByte(crockfordAlphabet, text[i]) + 1) % len(crockfordAlphabet)
+		typo := text[:i] + string(crockfordAlphabet[c]) + text[i+1:]
+		_, _, _, err := decodeBase32Share(typo)
+		require.Error(t, err, "typo at %d", i)
+		require.Equal(t, exitMalformedShare, exitCode(err))
+	}
+
+	tests := []struct {
+		name  string
+		share string
+		msg   string
+	}{
+		{"invalid character", "U" + text[1:], `invalid base32 character 'U' at position 1`},
+		{"two missing groups", text[:4] + text[12:], "checksum is invalid"},
+		{"extra character", text + "0", "invalid length"},
+		{"too short", text[:8], "invalid length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeBase32Share(tt.share)
+			require.ErrorContains(t, err, tt.msg)
+		})
+	}
+}
+
+func TestIsHexShare(t *testing.T) {
+	for share, want := range map[string]bool{
+		"1-abcd":          true,
+		"12-bip39.pad-ab": true,
+		"041G-2178-7WMA":  false,
+		"041G21787WMA":    false,
+		"-abcd":           false,
+		"1a-abcd":         false,
+	} {
+		require.Equal(t, want, isHexShare(share), share)
+	}
+}
+
+func TestSplitBase32(t *testing.T) {
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "base32", "--insecure-deterministic-seed", "base32", "my_secret", "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	var shares []string
+	for _, line := range strings.Split(out, "\n") {
+		if share, ok := strings.CutPrefix(line, "Share "); ok {
+			_, share, _ = strings.Cut(share, ": ")
+			shares = append(shares, share)
+		}
+	}
+	require.Len(t, shares, 3)
+	require.Contains(t, out, formatBase32Lines(shares[0]))
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", strings.ToLower(shares[2]) + "," + shares[0]})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+
+	// Base32 and hex shares of the same split can be mixed.
+	_, data, err := decodeShare(shares[1])
+	require.NoError(t, err)
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", shares[0] + "," + encodeShare(2, shareModeRaw, data)})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"split", "--output", "json", "--format", "base32", "--type", "bip39", "--compact", "--pad", "bucket", "--insecure-deterministic-seed", "base32", testMnemonic, "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	require.Contains(t, out, `"encoding": "base32"`)
+	require.Contains(t, out, `"mode": "bip39.pad"`)
+}
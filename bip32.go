@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/term"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Offline BIP-32 derivation, used by restore --verify-bip32 to check that a
+// restored mnemonic belongs to the expected wallet without displaying it.
+// Only public data (fingerprints, extended public keys and addresses) ever
+// leaves this file.
+
+const bip32HardenedOffset = 0x80000000
+
+// Extended public key version bytes for Bitcoin mainnet.
+const (
+	versionXpub = 0x0488b21e
+	versionZpub = 0x04b24746
+)
+
+// walletStandard describes one account derivation standard.
+type walletStandard struct {
+	Name    string
+	Purpose uint32
+	Version uint32
+	Address func(pubKey []byte) (string, error)
+}
+
+var walletStandards = []walletStandard{
+	{Name: "BIP-44 (legacy)", Purpose: 44, Version: versionXpub, Address: p2pkhAddress},
+	{Name: "BIP-84 (native segwit)", Purpose: 84, Version: versionZpub, Address: p2wpkhAddress},
+	{Name: "BIP-86 (taproot)", Purpose: 86, Version: versionXpub, Address: p2trAddress},
+}
+
+// walletSummary holds the public data derived from a mnemonic.
+type walletSummary struct {
+	Fingerprint string          `json:"fingerprint"`
+	Accounts    []walletAccount `json:"accounts"`
+}
+
+type walletAccount struct {
+	Standard    string          `json:"standard"`
+	Path        string          `json:"path"`
+	ExtendedKey string          `json:"extended_key"`
+	Addresses   []walletAddress `json:"addresses"`
+}
+
+type walletAddress struct {
+	Path    string `json:"path"`
+	Address string `json:"address"`
+}
+
+type extendedKey struct {
+	key         []byte
+	chainCode   []byte
+	depth       uint8
+	parentFP    []byte
+	childNumber uint32
+}
+
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	var k secp256k1.ModNScalar
+	if overflow := k.SetByteSlice(sum[:32]); overflow || k.IsZero() {
+		return nil, fmt.Errorf("seed produces an invalid master key")
+	}
+	k.Zero()
+	return &extendedKey{key: sum[:32], chainCode: sum[32:], parentFP: make([]byte, 4)}, nil
+}
+
+func (k *extendedKey) publicKey() []byte {
+	return secp256k1.PrivKeyFromBytes(k.key).PubKey().SerializeCompressed()
+}
+
+func (k *extendedKey) fingerprint() []byte {
+	return hash160(k.publicKey())[:4]
+}
+
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 37)
+	defer clear(data)
+	if index >= bip32HardenedOffset {
+		copy(data[1:], k.key)
+	} else {
+		copy(data, k.publicKey())
+	}
+	binary.BigEndian.PutUint32(data[33:], index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	defer clear(sum[:32])
+
+	var tweak, parent secp256k1.ModNScalar
+	defer tweak.Zero()
+	defer parent.Zero()
+	if overflow := tweak.SetByteSlice(sum[:32]); overflow {
+		return nil, fmt.Errorf("derivation at index %d produced an invalid key", index)
+	}
+	parent.SetByteSlice(k.key)
+	tweak.Add(&parent)
+	if tweak.IsZero() {
+		return nil, fmt.Errorf("derivation at index %d produced an invalid key", index)
+	}
+	childKey := tweak.Bytes()
+
+	return &extendedKey{
+		key:         childKey[:],
+		chainCode:   sum[32:],
+		depth:       k.depth + 1,
+		parentFP:    k.fingerprint(),
+		childNumber: index,
+	}, nil
+}
+
+func (k *extendedKey) derive(path []uint32) (*extendedKey, error) {
+	current := k
+	for _, index := range path {
+		next, err := current.child(index)
+		if current != k {
+			current.wipe()
+		}
+		if err != nil {
+			return nil, err
+		}
+		current = next
+	}
+	return current, nil
+}
+
+func (k *extendedKey) wipe() {
+	clear(k.key)
+	clear(k.chainCode)
+}
+
+// extendedPublicKey serializes the public half of the key as in BIP-32.
+func (k *extendedKey) extendedPublicKey(version uint32) string {
+	buf := make([]byte, 0, 78)
+	buf = binary.BigEndian.AppendUint32(buf, version)
+	buf = append(buf, k.depth)
+	buf = append(buf, k.parentFP...)
+	buf = binary.BigEndian.AppendUint32(buf, k.childNumber)
+	buf = append(buf, k.chainCode...)
+	buf = append(buf, k.publicKey()...)
+	return base58CheckEncode(buf)
+}
+
+func formatPath(path []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, index := range path {
+		if index >= bip32HardenedOffset {
+			fmt.Fprintf(&sb, "/%d'", index-bip32HardenedOffset)
+		} else {
+			fmt.Fprintf(&sb, "/%d", index)
+		}
+	}
+	return sb.String()
+}
+
+// deriveWalletSummary derives the master fingerprint and, for every standard,
+// the first account's extended public key and its first addressCount
+// receiving addresses.
+func deriveWalletSummary(mnemonic []byte, passphrase string, addressCount int) (walletSummary, error) {
+	normalized := normalizeMnemonic(mnemonic)
+	defer clear(normalized)
+	salt := []byte("mnemonic" + norm.NFKD.String(passphrase))
+	defer clear(salt)
+	seed := pbkdf2.Key(normalized, salt, 2048, 64, sha512.New)
+	defer clear(seed)
+
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return walletSummary{}, err
+	}
+	defer master.wipe()
+
+	summary := walletSummary{Fingerprint: hex.EncodeToString(master.fingerprint())}
+	for _, standard := range walletStandards {
+		accountPath := []uint32{
+			bip32HardenedOffset + standard.Purpose,
+			bip32HardenedOffset,
+			bip32HardenedOffset,
+		}
+		account, err := master.derive(accountPath)
+		if err != nil {
+			return walletSummary{}, err
+		}
+		receive, err := account.child(0)
+		if err != nil {
+			account.wipe()
+			return walletSummary{}, err
+		}
+
+		result := walletAccount{
+			Standard:    standard.Name,
+			Path:        formatPath(accountPath),
+			ExtendedKey: account.extendedPublicKey(standard.Version),
+		}
+		for i := 0; i < addressCount; i++ {
+			key, err := receive.child(uint32(i))
+			if err != nil {
+				return walletSummary{}, err
+			}
+			address, err := standard.Address(key.publicKey())
+			key.wipe()
+			if err != nil {
+				return walletSummary{}, err
+			}
+			result.Addresses = append(result.Addresses, walletAddress{
+				Path:    formatPath(append(accountPath, 0, uint32(i))),
+				Address: address,
+			})
+		}
+		account.wipe()
+		receive.wipe()
+		summary.Accounts = append(summary.Accounts, result)
+	}
+	return summary, nil
+}
+
+// readPassphrase reads a BIP-39 passphrase from stdin, without echo when
+// stdin is a terminal.
+func readPassphrase() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "BIP-39 passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+func formatWalletSummary(summary walletSummary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Master fingerprint: %s\n", summary.Fingerprint)
+	for _, account := range summary.Accounts {
+		fmt.Fprintf(&sb, "\n%s %s\n", account.Standard, account.Path)
+		fmt.Fprintf(&sb, "  %s\n", account.ExtendedKey)
+		for _, address := range account.Addresses {
+			fmt.Fprintf(&sb, "  %s  %s\n", address.Path, address.Address)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+func p2pkhAddress(pubKey []byte) (string, error) {
+	return base58CheckEncode(append([]byte{0x00}, hash160(pubKey)...)), nil
+}
+
+func p2wpkhAddress(pubKey []byte) (string, error) {
+	return segwitAddress("bc", 0, hash160(pubKey))
+}
+
+// p2trAddress returns the BIP-86 key-path-only taproot address for pubKey.
+func p2trAddress(pubKey []byte) (string, error) {
+	internal, err := secp256k1.ParsePubKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	var p secp256k1.JacobianPoint
+	internal.AsJacobian(&p)
+	// BIP-340 x-only keys always refer to the point with an even y.
+	if p.Y.IsOdd() {
+		p.Y.Negate(1).Normalize()
+	}
+
+	var tweak secp256k1.ModNScalar
+	if overflow := tweak.SetByteSlice(taggedHash("TapTweak", pubKey[1:])); overflow {
+		return "", fmt.Errorf("invalid taproot tweak")
+	}
+	var t, q secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&tweak, &t)
+	secp256k1.AddNonConst(&p, &t, &q)
+	q.ToAffine()
+
+	return segwitAddress("bc", 1, q.X.Bytes()[:])
+}
+
+func taggedHash(tag string, data []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58CheckEncode(payload []byte) string {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	data := append(bytes.Clone(payload), second[:4]...)
+
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from BIP-44, BIP-84 and BIP-86 for the all-"abandon" mnemonic.
+func TestDeriveWalletSummary(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	summary, err := deriveWalletSummary([]byte(mnemonic), "", 2)
+	require.NoError(t, err, "deriving wallet summary should not fail")
+	require.Equal(t, "73c5da0a", summary.Fingerprint)
+	require.Len(t, summary.Accounts, 3)
+
+	legacy := summary.Accounts[0]
+	require.Equal(t, "m/44'/0'/0'", legacy.Path)
+	require.Equal(t, "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj", legacy.ExtendedKey)
+	require.Equal(t, "m/44'/0'/0'/0/0", legacy.Addresses[0].Path)
+	require.Equal(t, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", legacy.Addresses[0].Address)
+
+	segwit := summary.Accounts[1]
+	require.Equal(t, "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs", segwit.ExtendedKey)
+	require.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", segwit.Addresses[0].Address)
+	require.Equal(t, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", segwit.Addresses[1].Address)
+
+	taproot := summary.Accounts[2]
+	require.Equal(t, "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ", taproot.ExtendedKey)
+	require.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", taproot.Addresses[0].Address)
+	require.Equal(t, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh", taproot.Addresses[1].Address)
+
+	output := formatWalletSummary(summary)
+	require.NotContains(t, output, "abandon", "summary should never contain the mnemonic")
+}
+
+func TestDeriveWalletSummaryPassphrase(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	withoutPassphrase, err := deriveWalletSummary([]byte(mnemonic), "", 1)
+	require.NoError(t, err)
+	withPassphrase, err := deriveWalletSummary([]byte(mnemonic), "TREZOR", 1)
+	require.NoError(t, err)
+	require.NotEqual(t, withoutPassphrase.Fingerprint, withPassphrase.Fingerprint, "passphrase should change the wallet")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/tofel/shamir/sss"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Secret types accepted by the --type flag of split and restore, besides
+// secretTypeBase64.
+const (
+	secretTypeText  = "text"
+	secretTypeBIP39 = "bip39"
+)
+
+// normalizeSecret prepares a secret of the given type for splitting, or
+// checks a restored one. The result is a new secure buffer that the caller
+// must destroy; text secrets are copied unchanged and base64 secrets are
+// decoded, so only splitting takes them.
+func normalizeSecret(secret []byte, secretType string) (*secureBuffer, error) {
+	switch secretType {
+	case secretTypeText:
+		return newSecureBufferFrom(secret)
+	case secretTypeBIP39:
+		mnemonic := normalizeMnemonic(secret)
+		defer clear(mnemonic)
+		entropy, err := mnemonicEntropy(mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		clear(entropy)
+		return newSecureBufferFrom(mnemonic)
+	case secretTypeBase64:
+		return decodeBase64Secret(secret)
+	default:
+		return nil, fmt.Errorf("unknown secret type %q, use %q, %q or %q", secretType, secretTypeText, secretTypeBIP39, secretTypeBase64)
+	}
+}
+
+// normalizeMnemonic applies the NFKD normalization required by BIP-39 and
+// collapses any run of whitespace into a single space. The result is a new
+// slice that the caller should clear.
+func normalizeMnemonic(mnemonic []byte) []byte {
+	decomposed := norm.NFKD.Bytes(mnemonic)
+	normalized := bytes.Join(bytes.Fields(decomposed), []byte(" "))
+	// NFKD returns its input when there is nothing to decompose.
+	if len(decomposed) > 0 && len(mnemonic) > 0 && &decomposed[0] != &mnemonic[0] {
+		clear(decomposed)
+	}
+	return normalized
+}
+
+var bip39WordIndex = func() map[string]int {
+	index := make(map[string]int, len(wordlists.English))
+	for i, word := range wordlists.English {
+		index[word] = i
+	}
+	return index
+}()
+
+// mnemonicEntropy checks the word count, that every word is on the English
+// BIP-39 word list and that the checksum matches, and returns the entropy.
+// Errors refer to words by position only, so they do not echo the secret.
+func mnemonicEntropy(mnemonic []byte) ([]byte, error) {
+	words := bytes.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("mnemonic must have 12, 15, 18, 21 or 24 words, got %d", len(words))
+	}
+
+	totalBits := len(words) * 11
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	data := make([]byte, (totalBits+7)/8)
+	defer clear(data)
+	for i, word := range words {
+		index, ok := bip39WordIndex[string(word)]
+		if !ok {
+			return nil, fmt.Errorf("word %d is not in the BIP-39 word list", i+1)
+		}
+		for bit := 0; bit < 11; bit++ {
+			pos := i*11 + bit
+			data[pos/8] |= byte(index>>(10-bit)&1) << (7 - pos%8)
+		}
+	}
+
+	entropy := bytes.Clone(data[:entropyBits/8])
+	hash := sha256.Sum256(entropy)
+	shift := 8 - checksumBits
+	if subtle.ConstantTimeByteEq(data[entropyBits/8]>>shift, hash[0]>>shift) != 1 {
+		clear(entropy)
+		return nil, fmt.Errorf("mnemonic checksum is invalid")
+	}
+	return entropy, nil
+}
+
+// mnemonicFromEntropy encodes entropy as an English BIP-39 mnemonic. The
+// result is a new slice that the caller should clear.
+func mnemonicFromEntropy(entropy []byte) ([]byte, error) {
+	switch len(entropy) {
+	case 16, 20, 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("entropy must be 16, 20, 24, 28 or 32 bytes, got %d", len(entropy))
+	}
+
+	hash := sha256.Sum256(entropy)
+	data := append(bytes.Clone(entropy), hash[0])
+	defer clear(data)
+
+	wordCount := (len(entropy)*8 + len(entropy)/4) / 11
+	// Sized for the longest words, so appending never leaves stale copies.
+	out := make([]byte, 0, wordCount*9)
+	for i := 0; i < wordCount; i++ {
+		index := 0
+		for bit := 0; bit < 11; bit++ {
+			pos := i*11 + bit
+			index = index<<1 | int(data[pos/8]>>(7-pos%8)&1)
+		}
+		if i > 0 {
+			out = append(out, ' ')
+		}
+		out = append(out, wordlists.English[index]...)
+	}
+	return out, nil
+}
+
+// bip39ChecksumSize is the number of SHA-256 bytes appended to the entropy in
+// compact shares. The entropy alone always maps to a mnemonic with a valid
+// checksum, so without it a wrong restore would go unnoticed.
+const bip39ChecksumSize = 2
+
+// mnemonicPayload returns the entropy behind a normalized mnemonic followed
+// by its checksum, as carried by compact shares, in a new secure buffer.
+func mnemonicPayload(mnemonic []byte) (*secureBuffer, error) {
+	entropy, err := mnemonicEntropy(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(entropy)
+
+	payload, err := newSecureBuffer(len(entropy) + bip39ChecksumSize)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(entropy)
+	copy(payload.Bytes(), entropy)
+	copy(payload.Bytes()[len(entropy):], checksum[:bip39ChecksumSize])
+	return payload, nil
+}
+
+// mnemonicFromPayload turns a restored compact payload back into a mnemonic
+// held in a new secure buffer.
+func mnemonicFromPayload(payload []byte) (*secureBuffer, error) {
+	if len(payload) < bip39ChecksumSize {
+		return nil, sss.NewError(sss.ErrMalformedShare, "restored entropy is too short", nil)
+	}
+	entropy := payload[:len(payload)-bip39ChecksumSize]
+	checksum := sha256.Sum256(entropy)
+	if subtle.ConstantTimeCompare(checksum[:bip39ChecksumSize], payload[len(entropy):]) != 1 {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "restored entropy checksum is invalid, the shares may be wrong or insufficient", nil)
+	}
+
+	mnemonic, err := mnemonicFromEntropy(entropy)
+	if err != nil {
+		return nil, sss.NewError(sss.ErrMalformedShare, "restored entropy has an invalid length", nil)
+	}
+	defer clear(mnemonic)
+	return newSecureBufferFrom(mnemonic)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "pen aunt text rotate donate sock shield pottery cloud toy tank sibling parrot oblige agent egg october angle short wolf survey frequent autumn desert"
+
+func TestNormalizeSecretBIP39(t *testing.T) {
+	type testCase struct {
+		name     string
+		secret   string
+		expected string
+		wantErr  bool
+	}
+
+	testCases := []testCase{
+		{
+			name:     "valid 24 words",
+			secret:   testMnemonic,
+			expected: testMnemonic,
+		},
+		{
+			name:     "valid 12 words",
+			secret:   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			expected: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		},
+		{
+			name:     "extra whitespace",
+			secret:   "  abandon abandon\tabandon abandon abandon abandon\nabandon abandon abandon abandon abandon  about\n",
+			expected: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		},
+		{
+			name:     "compatibility characters",
+			secret:   "ａｂａｎｄｏｎ abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			expected: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		},
+		{
+			name:    "wrong checksum",
+			secret:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+			wantErr: true,
+		},
+		{
+			name:    "unknown word",
+			secret:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon bitcoin",
+			wantErr: true,
+		},
+		{
+			name:    "wrong word count",
+			secret:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			wantErr: true,
+		},
+		{
+			name:    "plain text",
+			secret:  "my_secret",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			normalized, err := normalizeSecret([]byte(tc.secret), secretTypeBIP39)
+			if tc.wantErr {
+				require.Error(t, err, "invalid mnemonic should be rejected")
+				return
+			}
+			require.NoError(t, err, "valid mnemonic should be accepted")
+			require.Equal(t, tc.expected, string(normalized.Bytes()))
+		})
+	}
+}
+
+func TestNormalizeSecretText(t *testing.T) {
+	secret, err := normalizeSecret([]byte("  not a mnemonic "), secretTypeText)
+	require.NoError(t, err)
+	require.Equal(t, "  not a mnemonic ", string(secret.Bytes()), "text secrets should not be modified")
+
+	_, err = normalizeSecret([]byte("my_secret"), "unknown")
+	require.Error(t, err, "unknown secret type should be rejected")
+}
+
+func TestRestoreBIP39WithInsufficientShares(t *testing.T) {
+	encodedShares, _, err := splitPrepared(rand.Reader, []byte(testMnemonic), secretTypeText, false, "", 5, 3)
+	require.NoError(t, err)
+
+	restored, err := restoreSecret(getNshares(encodedShares, 2))
+	require.NoError(t, err, "the library decodes insufficient shares without an error")
+
+	_, err = normalizeSecret(restored.Bytes(), secretTypeBIP39)
+	require.Error(t, err, "a wrong restore should not pass mnemonic validation")
+}
+
+func TestSplitCompactMnemonic(t *testing.T) {
+	encodedShares, _, err := splitPrepared(rand.Reader, []byte(testMnemonic), secretTypeBIP39, true, "", 5, 3)
+	require.NoError(t, err, "splitting entropy should not fail")
+
+	textShares, _, err := splitPrepared(rand.Reader, []byte(testMnemonic), secretTypeText, false, "", 5, 3)
+	require.NoError(t, err)
+	textShare := strings.Split(textShares, ",")[1]
+
+	shares := strings.Split(encodedShares, ",")
+	for _, share := range shares {
+		require.True(t, strings.HasPrefix(strings.SplitN(share, "-", 2)[1], shareModeBIP39+"-"), "shares should record the bip39 mode")
+		require.Less(t, len(share), len(textShare)/3, "compact shares should be much shorter than text shares")
+	}
+
+	restored, err := restoreSecret(encodedShares)
+	require.NoError(t, err, "restoring from all shares should not fail")
+	require.Equal(t, testMnemonic, string(restored.Bytes()), "restored mnemonic should be the same as original one")
+
+	restored, err = restoreSecret(strings.Join(shares[2:], ","))
+	require.NoError(t, err, "restoring from threshold shares should not fail")
+	require.Equal(t, testMnemonic, string(restored.Bytes()), "restored mnemonic should be the same as original one")
+
+	_, err = restoreSecret(getNshares(encodedShares, 2))
+	require.Error(t, err, "restoring from insufficient shares should be detected")
+
+	_, err = restoreSecret(shares[0] + "," + textShare)
+	require.Error(t, err, "mixing share modes should fail")
+
+	_, err = restoreSecret("1-unknown-00ff,2-unknown-00fe")
+	require.Error(t, err, "unknown share mode should fail")
+}
+
+func TestMnemonicEntropy(t *testing.T) {
+	type testCase struct {
+		entropy  string
+		mnemonic string
+	}
+
+	// Vectors from the BIP-39 reference implementation.
+	testCases := []testCase{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		},
+		{
+			entropy:  "80808080808080808080808080808080",
+			mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		},
+		{
+			entropy:  "0000000000000000000000000000000000000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		},
+	}
+
+	for _, tc := range testCases {
+		entropy, err := hex.DecodeString(tc.entropy)
+		require.NoError(t, err)
+
+		mnemonic, err := mnemonicFromEntropy(entropy)
+		require.NoError(t, err)
+		require.Equal(t, tc.mnemonic, string(mnemonic))
+
+		decoded, err := mnemonicEntropy([]byte(tc.mnemonic))
+		require.NoError(t, err)
+		require.Equal(t, entropy, decoded)
+	}
+
+	_, err := mnemonicFromEntropy(make([]byte, 15))
+	require.Error(t, err, "entropy of an invalid length should be rejected")
+}
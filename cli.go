@@ -0,0 +1,326 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// command is a subcommand of the shamir CLI. setup registers the command's
+// flags and returns the function that runs it with the remaining positional
+// arguments, so help and completion can list flags without running anything.
+// Commands with subcommands only dispatch to them.
+type command struct {
+	name        string
+	args        string
+	summary     string
+	setup       func(flags *flag.FlagSet) func(args []string) int
+	subcommands []*command
+	// values are fixed positional arguments offered by shell completion.
+	values []string
+}
+
+// commands is filled in by init, since help and completion refer to it.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "split",
+			args:    "<secret> <threshold> <total_shares>",
+			summary: "Split a secret into shares",
+			setup:   splitCommand,
+		},
+		{
+			name:    "restore",
+			args:    "<encoded_shares>",
+			summary: "Restore a secret from shares",
+			setup:   restoreCommand,
+		},
+		{
+			name:    "inspect",
+			args:    "<encoded_shares>",
+			summary: "Describe shares without combining them",
+			setup:   inspectCommand,
+		},
+		{
+			name:    "verify",
+			args:    "<encoded_shares>",
+			summary: "Check that shares restore a valid secret without showing it",
+			setup:   verifyCommand,
+		},
+		{
+			name:    "vault-unseal",
+			summary: "Enter shares as unseal keys of a sealed Vault server",
+			setup:   vaultUnsealCommand,
+		},
+		{
+			name:    "dkg",
+			summary: "Generate shares of a new secret without a dealer",
+			subcommands: []*command{
+				{
+					name:    "simulate",
+					args:    "<threshold> <participants> [<length>]",
+					summary: "Run every participant in-process and print the shares",
+					setup:   dkgSimulateCommand,
+				},
+				{
+					name:    "deal",
+					args:    "<dir> <participant> <threshold> <participants> [<length>]",
+					summary: "Write one participant's commitment and sub-shares",
+					setup:   dkgDealCommand,
+				},
+				{
+					name:    "finalize",
+					args:    "<dir> <participant>",
+					summary: "Check and combine the sub-shares addressed to a participant",
+					setup:   dkgFinalizeCommand,
+				},
+			},
+		},
+		{
+			name:    "doctor",
+			summary: "Check the machine before a split or restore ceremony",
+			setup:   doctorCommand,
+		},
+		{
+			name:    "selftest",
+			summary: "Check this build against the known-answer test vectors",
+			setup:   selftestCommand,
+		},
+		{
+			name:    "version",
+			summary: "Print version and build information",
+			setup:   versionCommand,
+		},
+		{
+			name:    "completion",
+			args:    "<bash|zsh|fish>",
+			summary: "Print a shell completion script",
+			setup:   completionCommand,
+			values:  []string{"bash", "zsh", "fish"},
+		},
+		{
+			name:    "help",
+			args:    "[<command>...]",
+			summary: "Show help for a command",
+			setup:   helpCommand,
+		},
+	}
+
+	help := findCommand(commands, "help")
+	for _, cmd := range commands {
+		help.values = append(help.values, cmd.name)
+	}
+}
+
+func findCommand(list []*command, name string) *command {
+	for _, cmd := range list {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// runCLI runs the command named by args and returns the exit code.
+func runCLI(args []string) int {
+	if len(args) == 0 {
+		printMainUsage(os.Stderr)
+		return exitUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help":
+		printMainUsage(os.Stdout)
+		return exitOK
+	case "-version", "--version":
+		args = []string{"version"}
+	}
+	return runCommand(commands, nil, args)
+}
+
+func runCommand(list []*command, path []string, args []string) int {
+	cmd := findCommand(list, args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", strings.Join(append(path, args[0]), " "))
+		if len(path) == 0 {
+			fmt.Fprintln(os.Stderr, "Run 'shamir help' for a list of commands.")
+		} else {
+			fmt.Fprintf(os.Stderr, "Run 'shamir help %s' for a list of commands.\n", strings.Join(path, " "))
+		}
+		return exitUsage
+	}
+	path = append(path, cmd.name)
+	args = args[1:]
+
+	if len(cmd.subcommands) > 0 {
+		if len(args) == 0 {
+			printCommandUsage(os.Stderr, cmd, path)
+			return exitUsage
+		}
+		if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+			printCommandUsage(os.Stdout, cmd, path)
+			return exitOK
+		}
+		return runCommand(cmd.subcommands, path, args)
+	}
+
+	flags := flag.NewFlagSet(strings.Join(path, " "), flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	run := cmd.setup(flags)
+	if cmd.args != "" {
+		args = markPositional(flags, args)
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandUsage(os.Stdout, cmd, path)
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "Invalid arguments: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Run 'shamir %s --help' for usage.\n", strings.Join(path, " "))
+		return exitUsage
+	}
+
+	code := run(flags.Args())
+	if code == exitUsage {
+		fmt.Fprintf(os.Stderr, "Run 'shamir %s --help' for usage.\n", strings.Join(path, " "))
+	}
+	return code
+}
+
+// markPositional keeps positional arguments that look like flags working,
+// such as the secret in `split "-dash" 2 3`: an argument that starts with "-"
+// but names no flag of the command ends the flags, as if "--" came before
+// it. A value equal to a flag name still needs "--".
+func markPositional(flags *flag.FlagSet, args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return args
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "h" || name == "help" {
+			continue
+		}
+		f := flags.Lookup(name)
+		if f == nil {
+			return append(append(args[:i:i], "--"), args[i:]...)
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) {
+			i++
+		}
+	}
+	return args
+}
+
+// bindPositional keeps the positional syntax working next to named flags.
+// Every flag in names that was not given explicitly takes the next
+// positional argument, in order. The first required names must end up set.
+func bindPositional(flags *flag.FlagSet, required int, names ...string) error {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	args := flags.Args()
+	for i, name := range names {
+		if set[name] {
+			continue
+		}
+		if len(args) == 0 {
+			if i < required {
+				return sss.NewError(errUsage, fmt.Sprintf("missing --%s", name), nil)
+			}
+			continue
+		}
+		if err := flags.Set(name, args[0]); err != nil {
+			return sss.NewError(errUsage, fmt.Sprintf("invalid value %q for --%s", args[0], name), err)
+		}
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		return sss.NewError(errUsage, fmt.Sprintf("unexpected argument %q", args[0]), nil)
+	}
+	return nil
+}
+
+func printMainUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: shamir <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	printCommandList(w, commands)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'shamir <command> --help' for the flags of a command.")
+}
+
+func printCommandUsage(w io.Writer, cmd *command, path []string) {
+	name := strings.Join(path, " ")
+	if len(cmd.subcommands) > 0 {
+		fmt.Fprintf(w, "Usage: shamir %s <command> [flags] [arguments]\n\n%s.\n\nCommands:\n", name, cmd.summary)
+		printCommandList(w, cmd.subcommands)
+		return
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	cmd.setup(flags)
+	synopsis := "shamir " + name
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		synopsis += " [flags]"
+	}
+	if cmd.args != "" {
+		synopsis += " " + cmd.args
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n%s.\n", synopsis, cmd.summary)
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+}
+
+func printCommandList(w io.Writer, list []*command) {
+	width := 0
+	for _, cmd := range list {
+		width = max(width, len(cmd.name))
+	}
+	for _, cmd := range list {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.name, cmd.summary)
+	}
+}
+
+// commandFlags returns the flags registered by cmd in lexical order.
+func commandFlags(cmd *command) []*flag.Flag {
+	if cmd.setup == nil {
+		return nil
+	}
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.setup(flags)
+	var out []*flag.Flag
+	flags.VisitAll(func(f *flag.Flag) { out = append(out, f) })
+	return out
+}
+
+func helpCommand(flags *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		if len(args) == 0 {
+			printMainUsage(os.Stdout)
+			return exitOK
+		}
+		list := commands
+		var cmd *command
+		for i, name := range args {
+			cmd = findCommand(list, name)
+			if cmd == nil {
+				return failWith(errUsage, fmt.Sprintf("Unknown command %q", strings.Join(args[:i+1], " ")))
+			}
+			list = cmd.subcommands
+		}
+		printCommandUsage(os.Stdout, cmd, args)
+		return exitOK
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout runs fn and returns what it wrote to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+func TestBindPositional(t *testing.T) {
+	type testCase struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}
+
+	testCases := []testCase{
+		{name: "positional", args: []string{"dir", "3"}, expected: "dir 3 32"},
+		{name: "named", args: []string{"--participant", "3", "--dir", "dir"}, expected: "dir 3 32"},
+		{name: "mixed", args: []string{"--participant", "3", "dir", "16"}, expected: "dir 3 16"},
+		{name: "missing required", args: []string{"dir"}, wantErr: true},
+		{name: "invalid value", args: []string{"dir", "three"}, wantErr: true},
+		{name: "too many", args: []string{"dir", "3", "16", "extra"}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.String("dir", "", "")
+			flags.Int("participant", 0, "")
+			flags.Int("length", 32, "")
+			require.NoError(t, flags.Parse(tc.args))
+
+			err := bindPositional(flags, 2, "dir", "participant", "length")
+			if tc.wantErr {
+				require.ErrorIs(t, err, errUsage)
+				return
+			}
+			require.NoError(t, err)
+			var values []string
+			for _, name := range []string{"dir", "participant", "length"} {
+				values = append(values, flags.Lookup(name).Value.String())
+			}
+			require.Equal(t, tc.expected, strings.Join(values, " "))
+		})
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	type testCase struct {
+		name     string
+		args     []string
+		expected int
+		output   string
+	}
+
+	testCases := []testCase{
+		{name: "no command", args: nil, expected: exitUsage},
+		{name: "unknown command", args: []string{"frobnicate"}, expected: exitUsage},
+		{name: "main help", args: []string{"--help"}, expected: exitOK, output: "Commands:"},
+		{name: "help command", args: []string{"help", "dkg", "deal"}, expected: exitOK, output: "Usage: shamir dkg deal [flags] <dir> <participant>"},
+		{name: "command help", args: []string{"restore", "--help"}, expected: exitOK, output: "-verify-bip32"},
+		{name: "subcommand help", args: []string{"dkg", "--help"}, expected: exitOK, output: "finalize"},
+		{name: "unknown flag", args: []string{"split", "--bogus"}, expected: exitUsage},
+		{name: "unknown subcommand", args: []string{"dkg", "bogus"}, expected: exitUsage},
+		{name: "positional split", args: []string{"split", "my_secret", "2", "3"}, expected: exitOK, output: "3-"},
+		{name: "named split", args: []string{"split", "--threshold", "2", "--total", "3", "--secret", "my_secret"}, expected: exitOK, output: "3-"},
+		{name: "missing split argument", args: []string{"split", "my_secret", "2"}, expected: exitUsage},
+		{name: "secret starting with a dash", args: []string{"split", "-dash", "2", "3"}, expected: exitOK, output: "3-"},
+		{name: "secret after flags", args: []string{"split", "--type", "text", "--fingerprint", "--dash", "2", "3"}, expected: exitOK, output: "Fingerprint: "},
+		{name: "secret named like a flag", args: []string{"split", "--", "-type", "2", "3"}, expected: exitOK, output: "3-"},
+		{name: "secret named like a flag without --", args: []string{"split", "-type", "2", "3"}, expected: exitUsage},
+		{name: "version", args: []string{"--version"}, expected: exitOK, output: "shamir "},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var code int
+			output := captureStdout(t, func() { code = runCLI(tc.args) })
+			require.Equal(t, tc.expected, code)
+			require.Contains(t, output, tc.output)
+		})
+	}
+}
+
+func TestRestoreNamedShares(t *testing.T) {
+	encodedShares, _, err := splitPrepared(rand.Reader, []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+
+	for _, args := range [][]string{
+		{"restore", encodedShares},
+		{"restore", "--shares", encodedShares},
+	} {
+		var code int
+		output := captureStdout(t, func() { code = runCLI(args) })
+		require.Equal(t, exitOK, code)
+		require.Equal(t, "my_secret\n", output)
+	}
+}
+
+func TestSplitDashSecret(t *testing.T) {
+	for _, args := range [][]string{
+		{"split", "-dash", "2", "3"},
+		{"split", "--", "-type", "2", "3"},
+	} {
+		var code int
+		output := captureStdout(t, func() { code = runCLI(args) })
+		require.Equal(t, exitOK, code)
+		shares := strings.Fields(output)[0]
+
+		output = captureStdout(t, func() { code = runCLI([]string{"restore", shares}) })
+		require.Equal(t, exitOK, code)
+		require.Equal(t, args[len(args)-3]+"\n", output)
+	}
+}
+
+func TestPrintBuildInfo(t *testing.T) {
+	var buf bytes.Buffer
+	printBuildInfo(&buf, buildInfo{Version: "v1.2.3", Revision: "abc123", Modified: true, GoVersion: "go1.26", Platform: "linux/amd64"})
+	require.Equal(t, "shamir v1.2.3\ncommit: abc123 (modified)\ngo:     go1.26 linux/amd64\n", buf.String())
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Codex32 (BIP-93) shares are bech32-style strings such as
+// "ms12namea320zyxwvutsrqpnmlkjhgfedcaxrpp870hkkqrm". After the "ms1" prefix
+// come the threshold, a four-character identifier, the share index, the
+// secret and a BCH checksum. Shares are split character by character over
+// GF(32), so they can be checked, split and recovered by hand with the
+// Codex32 paper worksheets. Only the short checksum is implemented, which
+// covers secrets of 16 to 46 bytes.
+
+// Share formats accepted by split --format.
+const (
+	shareFormatHex     = "hex"
+	shareFormatCodex32 = "codex32"
+)
+
+const (
+	codex32Prefix = "ms1"
+	// codex32ShareIndices lists the share indices in the order split hands
+	// them out; "s" is reserved for the secret itself.
+	codex32ShareIndices = "acdefghjklmnpqrtuvwxyz023456789"
+	codex32SecretIndex  = 's'
+
+	codex32IDSize        = 4
+	codex32HeaderSize    = 2 + codex32IDSize
+	codex32ChecksumSize  = 13
+	codex32MaxDataSize   = 93
+	codex32MinSecretSize = 16
+	codex32MaxSecretSize = 46
+	codex32MaxThreshold  = 9
+)
+
+// codex32Residue holds the 65-bit BCH residue: bit 64 in hi, the rest in lo.
+type codex32Residue struct {
+	hi, lo uint64
+}
+
+var codex32Generator = [5]codex32Residue{
+	{1, 0x9dc500ce73fde210},
+	{1, 0xbfae00def77fe529},
+	{1, 0xfbd920fffe7bee52},
+	{1, 0x739640bdeee3fdad},
+	{0, 0x7729a039cfc75f5a},
+}
+
+var codex32Const = codex32Residue{1, 0x0ce0795c2fd1e62a}
+
+// codex32Polymod computes the BCH residue of 5-bit values.
+func codex32Polymod(values []byte) codex32Residue {
+	r := codex32Residue{0, 0x23181b3}
+	for _, v := range values {
+		b := r.hi<<4 | r.lo>>60
+		r.hi = r.lo >> 59 & 1
+		r.lo = r.lo<<5 ^ uint64(v)
+		for i, g := range codex32Generator {
+			mask := -(b >> i & 1)
+			r.hi ^= g.hi & mask
+			r.lo ^= g.lo & mask
+		}
+	}
+	return r
+}
+
+// codex32Checksum returns the 13 checksum values for data.
+func codex32Checksum(data []byte) []byte {
+	values := append(append([]byte(nil), data...), make([]byte, codex32ChecksumSize)...)
+	defer clear(values)
+	r := codex32Polymod(values)
+	r.hi ^= codex32Const.hi
+	r.lo ^= codex32Const.lo
+
+	checksum := make([]byte, codex32ChecksumSize)
+	for i := range checksum {
+		shift := 5 * (codex32ChecksumSize - 1 - i)
+		v := r.lo >> shift
+		if shift > 59 {
+			v |= r.hi << (64 - shift)
+		}
+		checksum[i] = byte(v & 31)
+	}
+	return checksum
+}
+
+func codex32ValidChecksum(data []byte) bool {
+	r := codex32Polymod(data)
+	return r == codex32Const
+}
+
+// gf32Mul multiplies two elements of GF(32) as used by bech32, reducing by
+// x^5 + x^3 + 1. Like the GF(256) code in sss, it does not branch on or index
+// by its operands.
+func gf32Mul(a, b byte) byte {
+	var r byte
+	for i := 5; i > 0; i-- {
+		r = (-(b >> (i - 1) & 1) & a) ^ (-(r >> 4 & 1) & 0x09) ^ (r << 1 & 31)
+	}
+	return r
+}
+
+// gf32Inverse returns a^30, the inverse of a non-zero a.
+func gf32Inverse(a byte) byte {
+	r := byte(1)
+	for i := 0; i < 30; i++ {
+		r = gf32Mul(r, a)
+	}
+	return r
+}
+
+// codex32ShareNumber numbers a share index in the order split hands them
+// out, starting at 1; the secret itself is 0.
+func codex32ShareNumber(index byte) int {
+	return strings.IndexByte(codex32ShareIndices, index) + 1
+}
+
+// codex32Share is a parsed share string; data holds its 5-bit values after
+// the prefix, checksum included.
+type codex32Share struct {
+	threshold int
+	id        string
+	index     byte
+	data      []byte
+}
+
+// decodeCodex32 parses and checks one share string.
+func decodeCodex32(share string) (codex32Share, error) {
+	if share != strings.ToLower(share) && share != strings.ToUpper(share) {
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 share mixes upper and lower case", nil)
+	}
+	share = strings.ToLower(share)
+	if !strings.HasPrefix(share, codex32Prefix) {
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 share must start with "+codex32Prefix, nil)
+	}
+	rest := share[len(codex32Prefix):]
+	if len(rest) > codex32MaxDataSize {
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 share is too long, long checksums are not supported", nil)
+	}
+	if len(rest) < codex32HeaderSize+codex32ChecksumSize+(codex32MinSecretSize*8+4)/5 {
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 share is too short", nil)
+	}
+	if (len(rest)-codex32HeaderSize-codex32ChecksumSize)*5%8 > 4 {
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 share has an invalid length", nil)
+	}
+
+	data := make([]byte, len(rest))
+	for i := range rest {
+		v := strings.IndexByte(bech32Charset, rest[i])
+		if v < 0 {
+			clear(data)
+			return codex32Share{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid codex32 character %q at position %d", rest[i], len(codex32Prefix)+i+1), nil)
+		}
+		data[i] = byte(v)
+	}
+	if !codex32ValidChecksum(data) {
+		clear(data)
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 checksum is invalid, check the share for typos", nil)
+	}
+
+	parsed := codex32Share{id: rest[1:5], index: rest[5], data: data}
+	switch k := rest[0]; {
+	case k == '0':
+		if parsed.index != codex32SecretIndex {
+			clear(data)
+			return codex32Share{}, sss.NewError(sss.ErrMalformedShare, "codex32 threshold 0 is only valid for an unshared secret", nil)
+		}
+		parsed.threshold = 1
+	case k >= '2' && k <= '9':
+		parsed.threshold = int(k - '0')
+	default:
+		clear(data)
+		return codex32Share{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid codex32 threshold %q", k), nil)
+	}
+	return parsed, nil
+}
+
+// encodeCodex32 returns the share string for data without its checksum.
+func encodeCodex32(data []byte) string {
+	checksum := codex32Checksum(data)
+	var sb strings.Builder
+	sb.WriteString(codex32Prefix)
+	for _, v := range data {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for _, v := range checksum {
+		sb.WriteByte(bech32Charset[v])
+	}
+	return sb.String()
+}
+
+// checkCodex32ID checks an identifier given by the user.
+func checkCodex32ID(id string) error {
+	if len(id) != codex32IDSize {
+		return fmt.Errorf("codex32 identifier must have %d characters", codex32IDSize)
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(bech32Charset, id[i]) < 0 {
+			return fmt.Errorf("codex32 identifier may only use the characters %s", bech32Charset)
+		}
+	}
+	return nil
+}
+
+// codex32Interpolate evaluates, at index x, the polynomials through the
+// shares, character by character.
+func codex32Interpolate(shares [][]byte, indices []byte, x byte) []byte {
+	out := make([]byte, len(shares[0]))
+	for i, share := range shares {
+		weight := byte(1)
+		for j, other := range indices {
+			if j != i {
+				weight = gf32Mul(weight, gf32Mul(x^other, gf32Inverse(indices[i]^other)))
+			}
+		}
+		for k, v := range share {
+			out[k] ^= gf32Mul(weight, v)
+		}
+	}
+	return out
+}
+
+// splitCodex32 splits a secret into codex32 shares with the given
+// identifier, or a random one when id is empty. As in BIP-93, the first
+// threshold-1 shares are random and the others are interpolated from them
+// and the secret.
+func splitCodex32(random io.Reader, secret []byte, id string, totalShares int, threshold int) (string, error) {
+	switch {
+	case threshold < 2 || threshold > codex32MaxThreshold:
+		return "", sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("codex32 threshold must be between 2 and %d", codex32MaxThreshold), nil)
+	case totalShares < threshold:
+		return "", sss.NewError(sss.ErrInvalidParameters, "parts cannot be less than threshold", nil)
+	case totalShares > len(codex32ShareIndices):
+		return "", sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("codex32 allows at most %d shares", len(codex32ShareIndices)), nil)
+	case len(secret) < codex32MinSecretSize || len(secret) > codex32MaxSecretSize:
+		return "", sss.NewError(sss.ErrInvalidSecret, fmt.Sprintf("codex32 secrets must be %d to %d bytes, got %d", codex32MinSecretSize, codex32MaxSecretSize, len(secret)), nil)
+	}
+
+	payload, err := convertBits(secret, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	defer clear(payload)
+	size := codex32HeaderSize + len(payload)
+	random32 := func(dst []byte) error {
+		if _, err := io.ReadFull(random, dst); err != nil {
+			return sss.NewError(sss.ErrRandomness, "failed to generate codex32 share", err)
+		}
+		for i := range dst {
+			dst[i] &= 31
+		}
+		return nil
+	}
+
+	header := make([]byte, codex32HeaderSize)
+	header[0] = byte(strings.IndexByte(bech32Charset, byte('0'+threshold)))
+	if id == "" {
+		if err := random32(header[1:5]); err != nil {
+			return "", err
+		}
+	} else {
+		if err := checkCodex32ID(id); err != nil {
+			return "", sss.NewError(sss.ErrInvalidParameters, "invalid codex32 identifier", err)
+		}
+		for i := 0; i < codex32IDSize; i++ {
+			header[1+i] = byte(strings.IndexByte(bech32Charset, id[i]))
+		}
+	}
+
+	withChecksum := func(index byte, body []byte) []byte {
+		data := make([]byte, 0, size+codex32ChecksumSize)
+		data = append(data, header[:5]...)
+		data = append(data, byte(strings.IndexByte(bech32Charset, index)))
+		data = append(data, body...)
+		return append(data, codex32Checksum(data)...)
+	}
+
+	points := [][]byte{withChecksum(codex32SecretIndex, payload)}
+	indices := []byte{byte(strings.IndexByte(bech32Charset, codex32SecretIndex))}
+	defer func() { wipeShares(points) }()
+	for i := 0; i < threshold-1; i++ {
+		body := make([]byte, len(payload))
+		if err := random32(body); err != nil {
+			return "", err
+		}
+		points = append(points, withChecksum(codex32ShareIndices[i], body))
+		indices = append(indices, byte(strings.IndexByte(bech32Charset, codex32ShareIndices[i])))
+		clear(body)
+	}
+
+	encoded := make([]string, totalShares)
+	for i := range encoded {
+		if i < threshold-1 {
+			data := points[i+1]
+			encoded[i] = encodeCodex32(data[:len(data)-codex32ChecksumSize])
+			continue
+		}
+		x := byte(strings.IndexByte(bech32Charset, codex32ShareIndices[i]))
+		data := codex32Interpolate(points, indices, x)
+		encoded[i] = encodeCodex32(data[:len(data)-codex32ChecksumSize])
+		clear(data)
+	}
+	return strings.Join(encoded, ","), nil
+}
+
+// splitCodex32Secret splits a normalized secret into codex32 shares and also
+// returns the share mode. Mnemonics are split as their entropy, which the
+// codex32 checksum already protects.
+func splitCodex32Secret(random io.Reader, secret []byte, secretType string, id string, totalShares int, threshold int) (string, string, error) {
+	if secretType != secretTypeBIP39 {
+		encoded, err := splitCodex32(random, secret, id, totalShares, threshold)
+		return encoded, shareModeRaw, err
+	}
+	entropy, err := mnemonicEntropy(secret)
+	if err != nil {
+		return "", "", err
+	}
+	defer clear(entropy)
+	encoded, err := splitCodex32(random, entropy, id, totalShares, threshold)
+	return encoded, shareModeBIP39, err
+}
+
+// codex32Format is the shareFormat of codex32 shares.
+type codex32Format struct {
+	id string
+}
+
+func (*codex32Format) name() string { return shareFormatCodex32 }
+
+func (f *codex32Format) setFlags(flags *flag.FlagSet) {
+	flags.StringVar(&f.id, "codex32-id", "", "four-character identifier for codex32 shares, random by default")
+}
+
+func (*codex32Format) check(opts splitOptions) error {
+	if opts.pad != "" {
+		return sss.NewError(errUsage, "--pad cannot be combined with --format codex32", nil)
+	}
+	return nil
+}
+
+func (f *codex32Format) split(random io.Reader, secret []byte, opts splitOptions) (splitShares, error) {
+	encoded, mode, err := splitCodex32Secret(random, secret, opts.secretType, f.id, opts.totalShares, opts.threshold)
+	return splitShares{encoded: encoded, mode: mode}, err
+}
+
+func (*codex32Format) write(w io.Writer, shares splitShares, opts splitOptions) error {
+	_, err := fmt.Fprintln(w, shares.encoded)
+	return err
+}
+
+func (*codex32Format) describe(encodedShare string) (jsonShare, []byte, error) {
+	return describeShare(encodedShare)
+}
+
+// isCodex32Shares reports whether encodedShares look like codex32 strings
+// rather than "N-hex" shares.
+func isCodex32Shares(encodedShares string) bool {
+	return strings.HasPrefix(strings.ToLower(encodedShares), codex32Prefix)
+}
+
+// combineCodex32 recovers the secret from codex32 shares into a new secure
+// buffer. Shares beyond the threshold must agree with the others.
+func combineCodex32(encodedShares string) (*secureBuffer, error) {
+	var shares []codex32Share
+	defer func() {
+		for _, share := range shares {
+			clear(share.data)
+		}
+	}()
+	seen := make(map[byte]bool)
+	for _, encoded := range strings.Split(encodedShares, ",") {
+		share, err := decodeCodex32(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+		first := shares[0]
+		if share.threshold != first.threshold || share.id != first.id || len(share.data) != len(first.data) {
+			return nil, sss.NewError(sss.ErrInconsistentShares, "codex32 shares differ in threshold, identifier or length", nil)
+		}
+		if seen[share.index] {
+			return nil, sss.NewError(sss.ErrDuplicateShare, fmt.Sprintf("codex32 share %q is given twice", share.index), nil)
+		}
+		seen[share.index] = true
+	}
+
+	threshold := shares[0].threshold
+	extra := shares[min(threshold, len(shares)):]
+	if len(shares) < threshold {
+		secretShare := slices.IndexFunc(shares, func(share codex32Share) bool { return share.index == codex32SecretIndex })
+		if secretShare < 0 {
+			return nil, sss.NewError(sss.ErrInsufficientShares, fmt.Sprintf("codex32 shares need %d shares, got %d", threshold, len(shares)), nil)
+		}
+		// The secret share holds the secret itself.
+		shares[0], shares[secretShare] = shares[secretShare], shares[0]
+		threshold, extra = 1, nil
+	}
+
+	points := make([][]byte, threshold)
+	indices := make([]byte, threshold)
+	for i := range points {
+		points[i] = shares[i].data
+		indices[i] = byte(strings.IndexByte(bech32Charset, shares[i].index))
+	}
+	for _, share := range extra {
+		x := byte(strings.IndexByte(bech32Charset, share.index))
+		expected := codex32Interpolate(points, indices, x)
+		match := subtle.ConstantTimeCompare(expected, share.data)
+		clear(expected)
+		if match != 1 {
+			return nil, sss.NewError(sss.ErrInconsistentShares, "codex32 shares do not belong to the same secret", nil)
+		}
+	}
+
+	data := codex32Interpolate(points, indices, byte(strings.IndexByte(bech32Charset, codex32SecretIndex)))
+	defer clear(data)
+	payload := data[codex32HeaderSize : len(data)-codex32ChecksumSize]
+	// The padding bits of interpolated shares need not be zero. They end up
+	// in a last partial byte, which is dropped.
+	secret, err := convertBits(payload, 5, 8, true)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(secret)
+	return newSecureBufferFrom(secret[:len(payload)*5/8])
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tofel/shamir/sss"
+)
+
+// Test vectors from BIP-93.
+const (
+	codex32Vector1       = "ms10testsxxxxxxxxxxxxxxxxxxxxxxxxxx4nzvca9cmczlw"
+	codex32Vector2ShareA = "MS12NAMEA320ZYXWVUTSRQPNMLKJHGFEDCAXRPP870HKKQRM"
+	codex32Vector2ShareC = "MS12NAMECACDEFGHJKLMNPQRSTUVWXYZ023FTR2GDZMPY6PN"
+	codex32Vector2ShareD = "MS12NAMEDLL4F8JLH4E5VDVULDLFXU2JHDNLSM97XVENRXEG"
+	codex32Vector2Secret = "MS12NAMES6XQGUZTTXKEQNJSJZV4JV3NZ5K3KWGSPHUH6EVW"
+)
+
+func TestCodex32Vectors(t *testing.T) {
+	tests := []struct {
+		name   string
+		shares string
+		secret string
+	}{
+		{"unshared secret", codex32Vector1, "318c6318c6318c6318c6318c6318c631"},
+		{"shares a and c", codex32Vector2ShareA + "," + codex32Vector2ShareC, "d1808e096b35b209ca12132b264662a5"},
+		{"shares d and a", codex32Vector2ShareD + "," + codex32Vector2ShareA, "d1808e096b35b209ca12132b264662a5"},
+		{"secret share", codex32Vector2Secret, "d1808e096b35b209ca12132b264662a5"},
+		{"secret share and share a", codex32Vector2ShareA + "," + codex32Vector2Secret, "d1808e096b35b209ca12132b264662a5"},
+		{"extra shares agree", strings.Join([]string{codex32Vector2ShareA, codex32Vector2ShareC, codex32Vector2ShareD}, ","), "d1808e096b35b209ca12132b264662a5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := restoreSecret(tt.shares)
+			require.NoError(t, err)
+			defer secret.Destroy()
+			require.Equal(t, tt.secret, hex.EncodeToString(secret.Bytes()))
+		})
+	}
+
+	// Splitting interpolates the same shares from the secret and share a.
+	var points [][]byte
+	var indices []byte
+	for _, s := range []string{codex32Vector2Secret, codex32Vector2ShareA} {
+		share, err := decodeCodex32(s)
+		require.NoError(t, err)
+		points = append(points, share.data)
+		indices = append(indices, byte(strings.IndexByte(bech32Charset, share.index)))
+	}
+	for _, want := range []string{codex32Vector2ShareC, codex32Vector2ShareD} {
+		x := byte(strings.IndexByte(bech32Charset, want[8]+'a'-'A'))
+		data := codex32Interpolate(points, indices, x)
+		require.Equal(t, strings.ToLower(want), encodeCodex32(data[:len(data)-codex32ChecksumSize]))
+	}
+}
+
+func TestGF32(t *testing.T) {
+	for a := byte(1); a < 32; a++ {
+		require.Equal(t, byte(1), gf32Mul(a, gf32Inverse(a)), "a = %d", a)
+		require.Equal(t, a, gf32Mul(a, 1))
+		require.Equal(t, byte(0), gf32Mul(a, 0))
+	}
+	// x * x^4 = x^5 = x^3 + 1
+	require.Equal(t, byte(9), gf32Mul(2, 16))
+}
+
+func TestDecodeCodex32Errors(t *testing.T) {
+	badThreshold := encodeCodex32(append([]byte{byte(strings.IndexByte(bech32Charset, 'x'))}, mustCodex32Data(t, codex32Vector1)[1:]...))
+	unsharedIndex := mustCodex32Data(t, codex32Vector1)
+	unsharedIndex[5] = byte(strings.IndexByte(bech32Charset, 'a'))
+
+	tests := []struct {
+		name  string
+		share string
+		msg   string
+	}{
+		{"typo", strings.Replace(codex32Vector1, "xxx", "xxz", 1), "checksum is invalid"},
+		{"swapped characters", "ms10testsxxxxxxxxxxxxxxxxxxxxxxxxxx4nzvca9cmczwl", "checksum is invalid"},
+		{"mixed case", "MS10testsxxxxxxxxxxxxxxxxxxxxxxxxxx4nzvca9cmczlw", "mixes upper and lower case"},
+		{"wrong prefix", "mx10testsxxxxxxxxxxxxxxxxxxxxxxxxxx4nzvca9cmczlw", "must start with ms1"},
+		{"invalid character", "ms10testsxxxxxxxxxxxxxxxxxxxxxxxxxb4nzvca9cmczlw", "invalid codex32 character 'b'"},
+		{"too short", "ms10testsxxxxxxxxxxxxxxxxxxxxxxxxx4nzvca9cmczlw", "too short"},
+		{"too long", "ms1" + strings.Repeat("q", codex32MaxDataSize+1), "long checksums are not supported"},
+		{"invalid threshold", badThreshold, "invalid codex32 threshold 'x'"},
+		{"threshold 0 share", encodeCodex32(unsharedIndex), "only valid for an unshared secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCodex32(tt.share)
+			require.ErrorContains(t, err, tt.msg)
+			require.Equal(t, exitMalformedShare, exitCode(err))
+		})
+	}
+}
+
+func mustCodex32Data(t *testing.T, share string) []byte {
+	t.Helper()
+	parsed, err := decodeCodex32(share)
+	require.NoError(t, err)
+	return parsed.data[:len(parsed.data)-codex32ChecksumSize]
+}
+
+func TestSplitCodex32(t *testing.T) {
+	for _, size := range []int{codex32MinSecretSize, 32, codex32MaxSecretSize} {
+		secret := make([]byte, size)
+		for i := range secret {
+			secret[i] = byte(i*37 + size)
+		}
+		for threshold := 2; threshold <= codex32MaxThreshold; threshold++ {
+			encoded, err := splitCodex32(deterministicReader("codex32"), secret, "", 31, threshold)
+			require.NoError(t, err)
+			shares := strings.Split(encoded, ",")
+			require.Len(t, shares, 31)
+			require.Equal(t, "ms1"+string(rune('0'+threshold)), shares[0][:4])
+
+			// Any quorum, taken from the end, restores the secret.
+			restored, err := restoreSecret(strings.Join(shares[31-threshold:], ","))
+			require.NoError(t, err)
+			require.Equal(t, secret, restored.Bytes(), "size %d, threshold %d", size, threshold)
+			restored.Destroy()
+
+			_, err = restoreSecret(strings.Join(shares[:threshold-1], ","))
+			require.ErrorIs(t, err, sss.ErrInsufficientShares)
+		}
+	}
+
+	encoded, err := splitCodex32(deterministicReader("codex32"), []byte("0123456789abcdef"), "cash", 3, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encoded, ",")
+	for i, index := range []string{"a", "c", "d"} {
+		require.True(t, strings.HasPrefix(shares[i], "ms12cash"+index), shares[i])
+	}
+}
+
+func TestCombineCodex32Errors(t *testing.T) {
+	other, err := splitCodex32(deterministicReader("other"), []byte("fedcba9876543210"), "name", 3, 2)
+	require.NoError(t, err)
+	otherShares := strings.Split(other, ",")
+
+	tests := []struct {
+		name   string
+		shares []string
+		code   int
+	}{
+		{"too few", []string{codex32Vector2ShareA}, exitInsufficientShares},
+		{"duplicate", []string{codex32Vector2ShareA, strings.ToLower(codex32Vector2ShareA)}, exitMalformedShare},
+		{"different identifier", []string{codex32Vector2ShareA, codex32Vector1}, exitInconsistentShares},
+		{"extra share of another secret", []string{codex32Vector2ShareA, codex32Vector2ShareC, otherShares[2]}, exitInconsistentShares},
+		{"hex share", []string{codex32Vector2ShareA, "2-abcd"}, exitInconsistentShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := restoreSecret(strings.Join(tt.shares, ","))
+			require.Error(t, err)
+			require.Equal(t, tt.code, exitCode(err), err.Error())
+		})
+	}
+}
+
+func TestCodex32CLI(t *testing.T) {
+	mnemonic := testMnemonic
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "codex32", "--codex32-id", "seed", "--type", "bip39", mnemonic, "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	shares := strings.Split(strings.TrimSpace(out), ",")
+	require.Len(t, shares, 3)
+	require.True(t, strings.HasPrefix(shares[2], "ms12seedd"))
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--type", "bip39", strings.ToUpper(shares[2]) + "," + strings.ToUpper(shares[0])})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, mnemonic+"\n", out)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"verify", "--type", "bip39", shares[1] + "," + shares[2]})
+	})
+	require.Equal(t, exitOK, code)
+	require.Contains(t, out, "codex32_checksum")
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "base64", "0123456789abcdef", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--codex32-id", "seed", "0123456789abcdef", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "codex32", "--pad", "bucket", "0123456789abcdef", "2", "3"}))
+	require.Equal(t, exitInvalidParameters, runCLI([]string{"split", "--format", "codex32", "--codex32-id", "seeb", "0123456789abcdef", "2", "3"}))
+	require.Equal(t, exitInvalidSecret, runCLI([]string{"split", "--format", "codex32", "too short", "2", "3"}))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+	"golang.org/x/term"
+)
+
+// shareEntryHelp explains how shares are entered at the prompts of readShare.
+const shareEntryHelp = "Enter each share on one line, or line by line with the check code at the end of every line and an empty line after the last one. Enter an empty line when all shares are in."
+
+// lineReader reads answers to prompts one line at a time, without echo when
+// the input is a terminal.
+type lineReader struct {
+	in       *bufio.Reader
+	w        io.Writer
+	fd       int
+	terminal bool
+}
+
+func newLineReader(in io.Reader, w io.Writer) *lineReader {
+	r := &lineReader{in: bufio.NewReader(in), w: w}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		r.fd, r.terminal = int(f.Fd()), true
+	}
+	return r
+}
+
+// prompt writes a prompt to w and returns the next line without its line
+// ending. The caller should clear the line. io.EOF is only returned when the
+// input ended before anything was read.
+func (r *lineReader) prompt(format string, args ...any) ([]byte, error) {
+	fmt.Fprintf(r.w, format, args...)
+	if r.terminal {
+		line, err := term.ReadPassword(r.fd)
+		fmt.Fprintln(r.w)
+		return line, err
+	}
+	line, err := r.in.ReadBytes('\n')
+	if errors.Is(err, io.EOF) && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n := len(line)
+	for n > 0 && (line[n-1] == '\n' || line[n-1] == '\r') {
+		n--
+	}
+	return line[:n], nil
+}
+
+// collectShares asks on w for shares until an empty line or the end of in.
+// Each share is read by readShare and asked for again if it does not decode.
+func collectShares(in io.Reader, w io.Writer) ([]string, error) {
+	r := newLineReader(in, w)
+	fmt.Fprintln(w, shareEntryHelp)
+	var shares []string
+	for {
+		share, err := r.readShare(len(shares) + 1)
+		if err != nil {
+			return nil, err
+		}
+		if share == "" {
+			break
+		}
+		if err := checkShare(share); err != nil {
+			fmt.Fprintf(w, "Share %d rejected: %v. Enter the whole share again.\n", len(shares)+1, err)
+			continue
+		}
+		shares = append(shares, share)
+	}
+	if len(shares) == 0 {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "no shares were entered", nil)
+	}
+	return shares, nil
+}
+
+// readShare asks for one share. A share is either entered whole on one line,
+// or typed line by line as printed on its sheet or by split --format base32:
+// the prefix such as "2-" or "ms1" on a line of its own if the share has one,
+// then the groups of each line followed by its check code, then an empty
+// line. A line whose check code does not match is asked for again. An empty
+// share is returned at an empty line or the end of the input.
+func (r *lineReader) readShare(number int) (string, error) {
+	line, err := r.prompt("Share %d: ", number)
+	if errors.Is(err, io.EOF) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	first := strings.TrimSpace(string(line))
+	clear(line)
+	if first == "" {
+		return "", nil
+	}
+	return r.readShareLines(number, first)
+}
+
+// readShareLines reads the rest of a share that starts with first. Only
+// shares typed line by line need more input.
+func (r *lineReader) readShareLines(number int, first string) (string, error) {
+	var text strings.Builder
+	base32 := false
+	switch {
+	case strings.EqualFold(first, codex32Prefix) || strings.HasSuffix(first, "-") && isHexShare(first+"0"):
+		text.WriteString(strings.ToLower(first))
+		first = ""
+	case wordsEncoding{}.detect(first):
+		return first, nil
+	case strings.ContainsAny(first, " \t"):
+		base32 = true
+	default:
+		return first, nil
+	}
+
+	for lineNumber := 1; ; {
+		if first == "" {
+			line, err := r.prompt("Share %d, line %d: ", number, lineNumber)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return "", err
+			}
+			first = strings.TrimSpace(string(line))
+			clear(line)
+			if first == "" {
+				return text.String(), nil
+			}
+		}
+		groups, err := parseShareLine(lineNumber, first, base32)
+		first = ""
+		if err != nil {
+			fmt.Fprintf(r.w, "Line %d rejected: %v. Enter it again.\n", lineNumber, err)
+			continue
+		}
+		text.WriteString(groups)
+		lineNumber++
+	}
+}
+
+// parseShareLine checks one typed line of groups against the check code at
+// its end and returns the groups without spaces.
+func parseShareLine(number int, line string, base32 bool) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("expected the groups of the line followed by its check code")
+	}
+	text := strings.Join(fields[:len(fields)-1], "")
+	if base32 {
+		var err error
+		if text, err = normalizeBase32(text); err != nil {
+			return "", err
+		}
+	} else {
+		text = strings.ToLower(text)
+	}
+	if lineChecksum(number, text) != strings.ToLower(fields[len(fields)-1]) {
+		return "", fmt.Errorf("the check code does not match, a group may be misheard or the line out of order")
+	}
+	return text, nil
+}
+
+// checkShare decodes a share to report mistakes while it can still be
+// entered again.
+func checkShare(encodedShare string) error {
+	if isCodex32Shares(encodedShare) {
+		share, err := decodeCodex32(encodedShare)
+		clear(share.data)
+		return err
+	}
+	_, share, err := decodeShare(encodedShare)
+	clear(share)
+	return err
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// typedLines returns how a share looks when typed from its printed lines.
+func typedLines(share string) string {
+	prefix, lines := sheetLines(share)
+	var sb strings.Builder
+	if prefix != "" {
+		sb.WriteString(prefix + "\n")
+	}
+	for _, line := range lines {
+		sb.WriteString(strings.Join(line.Groups, " ") + " " + line.Checksum + "\n")
+	}
+	return sb.String() + "\n"
+}
+
+func TestCollectShares(t *testing.T) {
+	encodedShares, _, err := splitPrepared(deterministicReader("collect"), []byte("a secret that spans a few lines"), secretTypeText, false, "", 4, 2)
+	require.NoError(t, err)
+	hexShares := strings.Split(encodedShares, ",")
+	base32Shares, err := reencodeShares(base32Encoding{}, encodedShares, 2, nil)
+	require.NoError(t, err)
+	wordsShares, err := reencodeShares(wordsEncoding{}, encodedShares, 2, nil)
+	require.NoError(t, err)
+	codex32, err := splitCodex32(deterministicReader("collect"), []byte("0123456789abcdef"), "test", 2, 2)
+	require.NoError(t, err)
+	codex32Shares := strings.Split(codex32, ",")
+
+	// The second line is first typed with one group misheard.
+	lines := strings.SplitAfter(typedLines(base32Shares[3]), "\n")
+	wrong := strings.Replace(lines[1], lines[1][:4], "ZZZZ", 1)
+	misheard := lines[0] + wrong + strings.Join(lines[1:], "")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		messages []string
+	}{
+		{
+			name:     "whole shares",
+			input:    hexShares[0] + "\n" + base32Shares[1] + "\n\n",
+			expected: []string{hexShares[0], base32Shares[1]},
+		},
+		{
+			name:     "words share",
+			input:    wordsShares[2] + "\n\n",
+			expected: []string{wordsShares[2]},
+		},
+		{
+			name:     "typed line by line",
+			input:    typedLines(base32Shares[0]) + typedLines(hexShares[2]) + typedLines(codex32Shares[1]),
+			expected: []string{strings.ReplaceAll(base32Shares[0], "-", ""), hexShares[2], codex32Shares[1]},
+		},
+		{
+			name:     "end of input",
+			input:    hexShares[1],
+			expected: []string{hexShares[1]},
+		},
+		{
+			name:     "misheard group is asked for again",
+			input:    misheard,
+			expected: []string{strings.ReplaceAll(base32Shares[3], "-", "")},
+			messages: []string{"Line 2 rejected: the check code does not match"},
+		},
+		{
+			name:     "bad share is asked for again",
+			input:    "1-zz\n" + hexShares[0] + "\n\n",
+			expected: []string{hexShares[0]},
+			messages: []string{"Share 1 rejected: invalid share encoding"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts bytes.Buffer
+			shares, err := collectShares(strings.NewReader(tt.input), &prompts)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, shares)
+			for _, msg := range tt.messages {
+				require.Contains(t, prompts.String(), msg)
+			}
+		})
+	}
+
+	_, err = collectShares(strings.NewReader("\n"), &bytes.Buffer{})
+	require.ErrorContains(t, err, "no shares were entered")
+	require.Equal(t, exitInsufficientShares, exitCode(err))
+}
+
+func TestParseShareLine(t *testing.T) {
+	text, err := parseShareLine(2, "ab0o il12 "+lineChecksum(2, "ab00112"), true)
+	require.ErrorContains(t, err, "check code")
+	require.Empty(t, text)
+
+	text, err = parseShareLine(2, "ab0o il12 "+strings.ToUpper(lineChecksum(2, "AB001112")), true)
+	require.NoError(t, err)
+	require.Equal(t, "AB001112", text)
+
+	_, err = parseShareLine(1, "ABCD", true)
+	require.ErrorContains(t, err, "followed by its check code")
+	_, err = parseShareLine(1, "ABCU 0000", true)
+	require.ErrorContains(t, err, "invalid base32 character")
+
+	text, err = parseShareLine(1, "AB12 cd "+lineChecksum(1, "ab12cd"), false)
+	require.NoError(t, err)
+	require.Equal(t, "ab12cd", text)
+}
+
+func TestRestoreInteractive(t *testing.T) {
+	encodedShares, _, err := splitPrepared(deterministicReader("interactive"), []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encodedShares, ",")
+
+	path := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(path, []byte(typedLines(shares[2])), 0o600))
+	stdin, err := os.Open(path)
+	require.NoError(t, err)
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--interactive", shares[0]})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Shell completion scripts are generated from the command table, so new
+// commands and flags are picked up without touching this file. Every script
+// tracks the subcommand path typed so far and offers the subcommands, flags
+// or fixed values that may follow it.
+
+type completionWord struct {
+	word        string
+	description string
+}
+
+// completionNode lists what may follow a subcommand path.
+type completionNode struct {
+	path  []string
+	words []completionWord
+}
+
+func completionNodes() []completionNode {
+	var nodes []completionNode
+	var collect func(path []string, list []*command) []completionWord
+	collect = func(path []string, list []*command) []completionWord {
+		var words []completionWord
+		for _, cmd := range list {
+			words = append(words, completionWord{cmd.name, cmd.summary})
+
+			sub := append(slices.Clone(path), cmd.name)
+			var subWords []completionWord
+			if len(cmd.subcommands) > 0 {
+				subWords = collect(sub, cmd.subcommands)
+			}
+			for _, f := range commandFlags(cmd) {
+				subWords = append(subWords, completionWord{"--" + f.Name, f.Usage})
+			}
+			for _, value := range cmd.values {
+				subWords = append(subWords, completionWord{value, ""})
+			}
+			nodes = append(nodes, completionNode{sub, subWords})
+		}
+		return words
+	}
+
+	root := collect(nil, commands)
+	root = append(root,
+		completionWord{"--help", "Show help"},
+		completionWord{"--version", "Print version and build information"},
+	)
+	return append([]completionNode{{nil, root}}, nodes...)
+}
+
+// completionKey renders a path the way the scripts build it: every
+// subcommand preceded by a space, and the empty string for the top level.
+func completionKey(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return " " + strings.Join(path, " ")
+}
+
+func writeBashCompletion(w io.Writer, nodes []completionNode) {
+	var paths []string
+	for _, node := range nodes[1:] {
+		paths = append(paths, fmt.Sprintf("%q", completionKey(node.path)))
+	}
+
+	fmt.Fprintln(w, "# bash completion for shamir, generated by `shamir completion bash`.")
+	fmt.Fprintln(w, "_shamir() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" cmdpath="" words="" i`)
+	fmt.Fprintln(w, "	for ((i = 1; i < COMP_CWORD; i++)); do")
+	fmt.Fprintln(w, `		case "$cmdpath ${COMP_WORDS[i]}" in`)
+	fmt.Fprintf(w, "			%s) cmdpath=\"$cmdpath ${COMP_WORDS[i]}\" ;;\n", strings.Join(paths, "|"))
+	fmt.Fprintln(w, "		esac")
+	fmt.Fprintln(w, "	done")
+	fmt.Fprintln(w, `	case "$cmdpath" in`)
+	for _, node := range nodes {
+		words := make([]string, len(node.words))
+		for i, word := range node.words {
+			words[i] = word.word
+		}
+		fmt.Fprintf(w, "		%q) words=%q ;;\n", completionKey(node.path), strings.Join(words, " "))
+	}
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, `	COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _shamir shamir")
+}
+
+// zshQuote quotes s for a single-quoted zsh string.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func writeZshCompletion(w io.Writer, nodes []completionNode) {
+	var paths []string
+	for _, node := range nodes[1:] {
+		paths = append(paths, fmt.Sprintf("%q", completionKey(node.path)))
+	}
+
+	fmt.Fprintln(w, "#compdef shamir")
+	fmt.Fprintln(w, "# zsh completion for shamir, generated by `shamir completion zsh`.")
+	fmt.Fprintln(w, "_shamir() {")
+	fmt.Fprintln(w, `	local cmdpath="" i`)
+	fmt.Fprintln(w, "	local -a candidates")
+	fmt.Fprintln(w, "	for ((i = 2; i < CURRENT; i++)); do")
+	fmt.Fprintln(w, `		case "$cmdpath ${words[i]}" in`)
+	fmt.Fprintf(w, "			(%s) cmdpath=\"$cmdpath ${words[i]}\" ;;\n", strings.Join(paths, "|"))
+	fmt.Fprintln(w, "		esac")
+	fmt.Fprintln(w, "	done")
+	fmt.Fprintln(w, `	case "$cmdpath" in`)
+	for _, node := range nodes {
+		candidates := make([]string, len(node.words))
+		for i, word := range node.words {
+			candidate := strings.ReplaceAll(word.word, ":", `\:`)
+			if word.description != "" {
+				candidate += ":" + word.description
+			}
+			candidates[i] = zshQuote(candidate)
+		}
+		fmt.Fprintf(w, "		(%q) candidates=(%s) ;;\n", completionKey(node.path), strings.Join(candidates, " "))
+	}
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, "	_describe 'shamir' candidates")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, `if [ "$funcstack[1]" = "_shamir" ]; then`)
+	fmt.Fprintln(w, `	_shamir "$@"`)
+	fmt.Fprintln(w, "else")
+	fmt.Fprintln(w, "	compdef _shamir shamir")
+	fmt.Fprintln(w, "fi")
+}
+
+// fishQuote quotes s for a single-quoted fish string.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func writeFishCompletion(w io.Writer, nodes []completionNode) {
+	var paths []string
+	for _, node := range nodes[1:] {
+		paths = append(paths, fishQuote(completionKey(node.path)))
+	}
+
+	fmt.Fprintln(w, "# fish completion for shamir, generated by `shamir completion fish`.")
+	fmt.Fprintln(w, "function __shamir_at")
+	fmt.Fprintln(w, "    set -l tokens (commandline -opc)")
+	fmt.Fprintln(w, "    set -e tokens[1]")
+	fmt.Fprintln(w, `    set -l cmdpath ""`)
+	fmt.Fprintln(w, "    for token in $tokens")
+	fmt.Fprintln(w, `        switch "$cmdpath $token"`)
+	fmt.Fprintf(w, "            case %s\n", strings.Join(paths, " "))
+	fmt.Fprintln(w, `                set cmdpath "$cmdpath $token"`)
+	fmt.Fprintln(w, "        end")
+	fmt.Fprintln(w, "    end")
+	fmt.Fprintln(w, `    test "$cmdpath" = "$argv[1]"`)
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w, "complete -c shamir -f")
+	for _, node := range nodes {
+		condition := fishQuote("__shamir_at " + fishQuote(completionKey(node.path)))
+		for _, word := range node.words {
+			option := "-a " + fishQuote(word.word)
+			if name, ok := strings.CutPrefix(word.word, "--"); ok {
+				option = "-l " + fishQuote(name)
+			}
+			line := fmt.Sprintf("complete -c shamir -n %s %s", condition, option)
+			if word.description != "" {
+				line += " -d " + fishQuote(word.description)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func completionCommand(flags *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		if len(args) != 1 {
+			return failWith(errUsage, "completion takes one shell: bash, zsh or fish")
+		}
+		nodes := completionNodes()
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout, nodes)
+		case "zsh":
+			writeZshCompletion(os.Stdout, nodes)
+		case "fish":
+			writeFishCompletion(os.Stdout, nodes)
+		default:
+			return failWith(errUsage, fmt.Sprintf("Unsupported shell %q, use bash, zsh or fish", args[0]))
+		}
+		return exitOK
+	}
+}
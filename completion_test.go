@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionNodes(t *testing.T) {
+	nodes := completionNodes()
+	byPath := make(map[string][]string)
+	for _, node := range nodes {
+		for _, word := range node.words {
+			byPath[completionKey(node.path)] = append(byPath[completionKey(node.path)], word.word)
+		}
+	}
+
+	require.Contains(t, byPath[""], "split")
+	require.Contains(t, byPath[""], "--version")
+	require.Contains(t, byPath[" split"], "--threshold")
+	require.Contains(t, byPath[" dkg"], "simulate")
+	require.Contains(t, byPath[" dkg deal"], "--participant")
+	require.Equal(t, []string{"bash", "zsh", "fish"}, byPath[" completion"])
+}
+
+func TestCompletionScripts(t *testing.T) {
+	nodes := completionNodes()
+	for _, write := range []func(*bytes.Buffer){
+		func(buf *bytes.Buffer) { writeBashCompletion(buf, nodes) },
+		func(buf *bytes.Buffer) { writeZshCompletion(buf, nodes) },
+		func(buf *bytes.Buffer) { writeFishCompletion(buf, nodes) },
+	} {
+		var buf bytes.Buffer
+		write(&buf)
+		require.Contains(t, buf.String(), "verify-bip32", "scripts should list restore flags")
+		require.Contains(t, buf.String(), "finalize", "scripts should list nested subcommands")
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	var buf bytes.Buffer
+	writeBashCompletion(&buf, completionNodes())
+	script := filepath.Join(t.TempDir(), "shamir.bash")
+	require.NoError(t, os.WriteFile(script, buf.Bytes(), 0o600))
+
+	complete := func(words ...string) string {
+		cmd := exec.Command(bash, "-c", `source "$0"; COMP_WORDS=("${@}"); COMP_CWORD=$((${#COMP_WORDS[@]} - 1)); _shamir; echo "${COMPREPLY[*]}"`, script)
+		cmd.Args = append(cmd.Args, words...)
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+
+	require.Equal(t, "restore", complete("shamir", "res"))
+	require.Equal(t, "--participant --participants", complete("shamir", "dkg", "deal", "--participant"))
+	require.Equal(t, "zsh", complete("shamir", "completion", "z"))
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Dealerless key generation. Every participant deals a random contribution
+// with its own polynomial and sends one sub-share to each other participant,
+// as in the dealing round of Pedersen's DKG. A participant's final share is
+// the sum of the sub-shares it received, so the joint secret (the sum of all
+// contributions) is never assembled anywhere, yet the shares combine exactly
+// like split output.
+//
+// This is not verifiable secret sharing. GF(2^8) has no homomorphic
+// commitments, so instead of Feldman or Pedersen commitments each dealer
+// publishes a salted hash of every sub-share. That detects sub-shares altered
+// in transit or swapped after the commitment round, but it cannot show that a
+// sub-share lies on the dealer's polynomial.
+
+const (
+	dkgVersion       = 1
+	dkgDefaultLength = 32
+	dkgSaltSize      = 16
+)
+
+// dkgCommitment is broadcast by a dealer to all participants.
+type dkgCommitment struct {
+	Version      int      `json:"version"`
+	Dealer       int      `json:"dealer"`
+	Threshold    int      `json:"threshold"`
+	Participants int      `json:"participants"`
+	Length       int      `json:"length"`
+	Digests      []string `json:"digests"`
+}
+
+// dkgSubShare is sent privately from a dealer to a single recipient.
+type dkgSubShare struct {
+	Dealer    int    `json:"dealer"`
+	Recipient int    `json:"recipient"`
+	Salt      string `json:"salt"`
+	Value     string `json:"value"`
+}
+
+func validateDKGParams(threshold, participants, length int) error {
+	if threshold < 2 {
+		return sss.NewError(sss.ErrInvalidParameters, "threshold must be at least 2", nil)
+	}
+	if participants < threshold {
+		return sss.NewError(sss.ErrInvalidParameters, "participants cannot be less than threshold", nil)
+	}
+	if participants > 255 {
+		return sss.NewError(sss.ErrInvalidParameters, "participants cannot exceed 255", nil)
+	}
+	if length < 1 {
+		return sss.NewError(sss.ErrInvalidParameters, "secret length must be at least 1 byte", nil)
+	}
+	return nil
+}
+
+func dkgDigest(dealer, recipient int, salt, value []byte) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte{uint8(dealer), uint8(recipient)})
+	h.Write(value)
+	return h.Sum(nil)
+}
+
+// dkgDeal runs the dealing round for one participant with randomness from
+// random. Participant indexes start at 1 and double as the x coordinate of
+// the participant's share.
+func dkgDeal(random io.Reader, dealer, threshold, participants, length int) (dkgCommitment, []dkgSubShare, error) {
+	if err := validateDKGParams(threshold, participants, length); err != nil {
+		return dkgCommitment{}, nil, err
+	}
+	if dealer < 1 || dealer > participants {
+		return dkgCommitment{}, nil, sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("participant must be between 1 and %d", participants), nil)
+	}
+
+	// This dealer's random contribution to the joint secret is shared with
+	// the ordinary splitting code, evaluated at the participant indexes.
+	contribution := make([]byte, length)
+	defer clear(contribution)
+	if _, err := io.ReadFull(random, contribution); err != nil {
+		return dkgCommitment{}, nil, sss.NewError(sss.ErrRandomness, "failed to generate contribution", err)
+	}
+	xCoordinates := make([]byte, participants)
+	for i := range xCoordinates {
+		xCoordinates[i] = byte(i + 1)
+	}
+	values, err := sss.SplitWithCoordinatesFrom(random, contribution, xCoordinates, threshold)
+	if err != nil {
+		return dkgCommitment{}, nil, err
+	}
+	defer func() {
+		for _, value := range values {
+			clear(value)
+		}
+	}()
+	for i := range values {
+		values[i] = values[i][:length]
+	}
+
+	commitment := dkgCommitment{
+		Version:      dkgVersion,
+		Dealer:       dealer,
+		Threshold:    threshold,
+		Participants: participants,
+		Length:       length,
+		Digests:      make([]string, participants),
+	}
+	subShares := make([]dkgSubShare, participants)
+	for i, value := range values {
+		salt := make([]byte, dkgSaltSize)
+		if _, err := io.ReadFull(random, salt); err != nil {
+			return dkgCommitment{}, nil, sss.NewError(sss.ErrRandomness, "failed to generate salt", err)
+		}
+		commitment.Digests[i] = hex.EncodeToString(dkgDigest(dealer, i+1, salt, value))
+		subShares[i] = dkgSubShare{
+			Dealer:    dealer,
+			Recipient: i + 1,
+			Salt:      hex.EncodeToString(salt),
+			Value:     hex.EncodeToString(value),
+		}
+	}
+	return commitment, subShares, nil
+}
+
+// dkgFinalize checks the sub-shares received by recipient against the
+// dealers' commitments and sums them into the recipient's share.
+func dkgFinalize(recipient int, commitments []dkgCommitment, subShares []dkgSubShare) ([]byte, error) {
+	if len(commitments) == 0 {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "no commitments found", nil)
+	}
+	first := commitments[0]
+	if err := validateDKGParams(first.Threshold, first.Participants, first.Length); err != nil {
+		return nil, err
+	}
+	if recipient < 1 || recipient > first.Participants {
+		return nil, sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("participant must be between 1 and %d", first.Participants), nil)
+	}
+
+	byDealer := make(map[int]dkgCommitment, len(commitments))
+	for _, c := range commitments {
+		if c.Version != dkgVersion {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("unsupported commitment version %d from dealer %d", c.Version, c.Dealer), nil)
+		}
+		if c.Threshold != first.Threshold || c.Participants != first.Participants || c.Length != first.Length {
+			return nil, sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("dealer %d uses different parameters", c.Dealer), nil)
+		}
+		if len(c.Digests) != c.Participants {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("dealer %d committed to %d sub-shares, expected %d", c.Dealer, len(c.Digests), c.Participants), nil)
+		}
+		if _, exists := byDealer[c.Dealer]; exists {
+			return nil, sss.NewError(sss.ErrDuplicateShare, fmt.Sprintf("duplicate commitment from dealer %d", c.Dealer), nil)
+		}
+		byDealer[c.Dealer] = c
+	}
+	for dealer := 1; dealer <= first.Participants; dealer++ {
+		if _, ok := byDealer[dealer]; !ok {
+			return nil, sss.NewError(sss.ErrInsufficientShares, fmt.Sprintf("missing commitment from dealer %d", dealer), nil)
+		}
+	}
+
+	share := make([]byte, first.Length+1)
+	share[first.Length] = uint8(recipient)
+	received := make(map[int]bool, len(subShares))
+	for _, s := range subShares {
+		if s.Recipient != recipient {
+			return nil, sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("sub-share from dealer %d is addressed to participant %d", s.Dealer, s.Recipient), nil)
+		}
+		c, ok := byDealer[s.Dealer]
+		if !ok {
+			return nil, sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("sub-share from unknown dealer %d", s.Dealer), nil)
+		}
+		if received[s.Dealer] {
+			return nil, sss.NewError(sss.ErrDuplicateShare, fmt.Sprintf("duplicate sub-share from dealer %d", s.Dealer), nil)
+		}
+		received[s.Dealer] = true
+
+		salt, err := hex.DecodeString(s.Salt)
+		if err != nil {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid salt from dealer %d", s.Dealer), err)
+		}
+		value, err := hex.DecodeString(s.Value)
+		if err != nil {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid sub-share from dealer %d", s.Dealer), err)
+		}
+		if len(value) != first.Length {
+			return nil, sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("sub-share from dealer %d has length %d, expected %d", s.Dealer, len(value), first.Length), nil)
+		}
+		expected, err := hex.DecodeString(c.Digests[recipient-1])
+		if err != nil {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid commitment from dealer %d", s.Dealer), err)
+		}
+		if subtle.ConstantTimeCompare(expected, dkgDigest(s.Dealer, recipient, salt, value)) != 1 {
+			return nil, sss.NewError(sss.ErrVerificationFailed, fmt.Sprintf("sub-share from dealer %d does not match its commitment", s.Dealer), nil)
+		}
+		// Addition in GF(2^8) is XOR.
+		for idx := range value {
+			share[idx] ^= value[idx]
+		}
+		clear(value)
+	}
+	if len(received) != first.Participants {
+		return nil, sss.NewError(sss.ErrInsufficientShares, fmt.Sprintf("received %d sub-shares, expected %d", len(received), first.Participants), nil)
+	}
+	return share, nil
+}
+
+// simulateDKG runs every participant in-process with randomness from random
+// and returns the encoded shares of a fresh secret that no single
+// participant has seen.
+func simulateDKG(random io.Reader, threshold, participants, length int) (string, error) {
+	if err := validateDKGParams(threshold, participants, length); err != nil {
+		return "", err
+	}
+
+	commitments := make([]dkgCommitment, participants)
+	inboxes := make([][]dkgSubShare, participants)
+	for dealer := 1; dealer <= participants; dealer++ {
+		commitment, subShares, err := dkgDeal(random, dealer, threshold, participants, length)
+		if err != nil {
+			return "", err
+		}
+		commitments[dealer-1] = commitment
+		for _, s := range subShares {
+			inboxes[s.Recipient-1] = append(inboxes[s.Recipient-1], s)
+		}
+	}
+
+	shares := make([][]byte, participants)
+	for recipient := 1; recipient <= participants; recipient++ {
+		share, err := dkgFinalize(recipient, commitments, inboxes[recipient-1])
+		if err != nil {
+			return "", err
+		}
+		shares[recipient-1] = share
+	}
+	return encodeShares(shareModeRaw, shares), nil
+}
+
+func dkgCommitmentPath(dir string, dealer int) string {
+	return filepath.Join(dir, fmt.Sprintf("commitment-%d.json", dealer))
+}
+
+// dkgRecipientDir is the subdirectory of a dealer's outbox that holds the
+// sub-share for one recipient.
+func dkgRecipientDir(outbox string, recipient int) string {
+	return filepath.Join(outbox, fmt.Sprintf("participant-%d", recipient))
+}
+
+func dkgSubSharePath(dir string, dealer, recipient int) string {
+	return filepath.Join(dir, fmt.Sprintf("share-%d-to-%d.json", dealer, recipient))
+}
+
+// checkDKGPrivateDir refuses a private directory that is the shared one,
+// where every participant could read the sub-shares and rebuild the secret.
+func checkDKGPrivateDir(shared, private, flagName string) error {
+	if private == "" {
+		return sss.NewError(errUsage, flagName+" is required, sub-shares must not go to the shared directory", nil)
+	}
+	sharedInfo, err := os.Stat(shared)
+	if err != nil {
+		return err
+	}
+	privateInfo, err := os.Stat(private)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(sharedInfo, privateInfo) {
+		return sss.NewError(errUsage, flagName+" must not be the shared directory, every participant could read the sub-shares there", nil)
+	}
+	return nil
+}
+
+// writeDKGDeal runs the dealing round for one participant. The public
+// commitment goes to the shared dir; the private sub-shares go to outbox, in
+// one subdirectory per recipient. Delivering each subdirectory privately to
+// its recipient is left to the participants.
+func writeDKGDeal(random io.Reader, dir, outbox string, dealer, threshold, participants, length int) error {
+	if err := checkDKGPrivateDir(dir, outbox, "--outbox"); err != nil {
+		return err
+	}
+	commitment, subShares, err := dkgDeal(random, dealer, threshold, participants, length)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(dkgCommitmentPath(dir, dealer), commitment); err != nil {
+		return err
+	}
+	for _, s := range subShares {
+		recipientDir := dkgRecipientDir(outbox, s.Recipient)
+		if err := os.Mkdir(recipientDir, 0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if err := writeJSONFile(dkgSubSharePath(recipientDir, s.Dealer, s.Recipient), s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDKGShare collects the commitments from the shared dir and the
+// sub-shares addressed to recipient from its private inbox, and returns the
+// recipient's encoded share.
+func readDKGShare(dir, inbox string, recipient int) (string, error) {
+	if err := checkDKGPrivateDir(dir, inbox, "--inbox"); err != nil {
+		return "", err
+	}
+	commitmentFiles, err := filepath.Glob(filepath.Join(dir, "commitment-*.json"))
+	if err != nil {
+		return "", err
+	}
+	commitments := make([]dkgCommitment, len(commitmentFiles))
+	for i, path := range commitmentFiles {
+		if err := readJSONFile(path, &commitments[i]); err != nil {
+			return "", err
+		}
+	}
+
+	subShareFiles, err := filepath.Glob(filepath.Join(inbox, fmt.Sprintf("share-*-to-%d.json", recipient)))
+	if err != nil {
+		return "", err
+	}
+	subShares := make([]dkgSubShare, len(subShareFiles))
+	for i, path := range subShareFiles {
+		if err := readJSONFile(path, &subShares[i]); err != nil {
+			return "", err
+		}
+	}
+
+	share, err := dkgFinalize(recipient, commitments, subShares)
+	if err != nil {
+		return "", err
+	}
+	return encodeShare(recipient, shareModeRaw, share), nil
+}
+
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// openDKGRandomSource opens the randomness source of the dealing commands
+// like split does, warning about a deterministic one.
+func openDKGRandomSource(entropyFile, deterministicSeed string) (io.Reader, func(), error) {
+	random, randomSource, closeRandom, err := openRandomSource(entropyFile, deterministicSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if randomSource == randomSourceInsecureDeterministic {
+		fmt.Fprintln(os.Stderr, insecureDeterministicWarning)
+	}
+	return random, closeRandom, nil
+}
+
+func dkgSimulateCommand(flags *flag.FlagSet) func(args []string) int {
+	threshold := flags.Int("threshold", 0, "number of shares needed to restore the secret")
+	participants := flags.Int("participants", 0, "number of participants")
+	length := flags.Int("length", dkgDefaultLength, "length of the generated secret in bytes")
+	entropyFile := flags.String("entropy-file", "", "read all randomness from this file or device instead of the system")
+	deterministicSeed := flags.String("insecure-deterministic-seed", "", "INSECURE, for tests only: derive all randomness from this seed")
+
+	return func(args []string) int {
+		if err := bindPositional(flags, 2, "threshold", "participants", "length"); err != nil {
+			return fail("Invalid arguments", err)
+		}
+		if err := selfTest(); err != nil {
+			return fail("Refusing to generate shares", err)
+		}
+		random, closeRandom, err := openDKGRandomSource(*entropyFile, *deterministicSeed)
+		if err != nil {
+			return fail("Invalid randomness source", err)
+		}
+		defer closeRandom()
+
+		encoded, err := simulateDKG(random, *threshold, *participants, *length)
+		if err != nil {
+			return fail("Error generating shares", err)
+		}
+		fmt.Println(encoded)
+		return exitOK
+	}
+}
+
+func dkgDealCommand(flags *flag.FlagSet) func(args []string) int {
+	dir := flags.String("dir", "", "directory shared by all participants, for the public commitments")
+	outbox := flags.String("outbox", "", "private directory for the sub-shares dealt, one subdirectory per recipient")
+	dealer := flags.Int("participant", 0, "index of the dealing participant, starting at 1")
+	threshold := flags.Int("threshold", 0, "number of shares needed to restore the secret")
+	participants := flags.Int("participants", 0, "number of participants")
+	length := flags.Int("length", dkgDefaultLength, "length of the generated secret in bytes")
+	entropyFile := flags.String("entropy-file", "", "read all randomness from this file or device instead of the system")
+	deterministicSeed := flags.String("insecure-deterministic-seed", "", "INSECURE, for tests only: derive all randomness from this seed")
+
+	return func(args []string) int {
+		if err := bindPositional(flags, 4, "dir", "participant", "threshold", "participants", "length"); err != nil {
+			return fail("Invalid arguments", err)
+		}
+		if err := selfTest(); err != nil {
+			return fail("Refusing to deal", err)
+		}
+		random, closeRandom, err := openDKGRandomSource(*entropyFile, *deterministicSeed)
+		if err != nil {
+			return fail("Invalid randomness source", err)
+		}
+		defer closeRandom()
+
+		if err := writeDKGDeal(random, *dir, *outbox, *dealer, *threshold, *participants, *length); err != nil {
+			return fail("Error dealing", err)
+		}
+		return exitOK
+	}
+}
+
+func dkgFinalizeCommand(flags *flag.FlagSet) func(args []string) int {
+	dir := flags.String("dir", "", "directory shared by all participants, for the public commitments")
+	inbox := flags.String("inbox", "", "private directory holding the sub-shares dealt to this participant")
+	recipient := flags.Int("participant", 0, "index of the finalizing participant, starting at 1")
+
+	return func(args []string) int {
+		if err := bindPositional(flags, 2, "dir", "participant"); err != nil {
+			return fail("Invalid arguments", err)
+		}
+
+		encoded, err := readDKGShare(*dir, *inbox, *recipient)
+		if err != nil {
+			return fail("Error finalizing share", err)
+		}
+		fmt.Println(encoded)
+		return exitOK
+	}
+}
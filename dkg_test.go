@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tofel/shamir/sss"
+)
+
+func TestSimulateDKG(t *testing.T) {
+	type testCase struct {
+		name         string
+		threshold    int
+		participants int
+		length       int
+	}
+
+	testCases := []testCase{
+		{
+			name:         "2 of 3",
+			threshold:    2,
+			participants: 3,
+			length:       32,
+		},
+		{
+			name:         "3 of 5 short",
+			threshold:    3,
+			participants: 5,
+			length:       1,
+		},
+		{
+			name:         "all required",
+			threshold:    4,
+			participants: 4,
+			length:       16,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encodedShares, err := simulateDKG(rand.Reader, tc.threshold, tc.participants, tc.length)
+			require.NoError(t, err, "simulating dkg should not fail")
+
+			shares := strings.Split(encodedShares, ",")
+			require.Len(t, shares, tc.participants)
+
+			secret, err := restoreSecret(encodedShares)
+			require.NoError(t, err, "restoring from all shares should not fail")
+			require.Len(t, secret.Bytes(), tc.length)
+
+			restored, err := restoreSecret(strings.Join(shares[len(shares)-tc.threshold:], ","))
+			require.NoError(t, err, "restoring from threshold shares should not fail")
+			require.Equal(t, secret.Bytes(), restored.Bytes(), "any threshold subset should restore the same secret")
+		})
+	}
+}
+
+func TestIncorrectDKG(t *testing.T) {
+	_, err := simulateDKG(rand.Reader, 1, 3, 32)
+	require.Error(t, err, "threshold below 2 should fail")
+
+	_, err = simulateDKG(rand.Reader, 3, 2, 32)
+	require.Error(t, err, "threshold above participants should fail")
+
+	_, err = simulateDKG(rand.Reader, 2, 3, 0)
+	require.Error(t, err, "empty secret should fail")
+
+	_, _, err = dkgDeal(rand.Reader, 4, 2, 3, 32)
+	require.Error(t, err, "dealer outside participants should fail")
+
+	_, _, err = dkgDeal(strings.NewReader("short"), 1, 2, 3, 32)
+	require.Equal(t, exitRandomness, exitCode(err), "exhausted randomness should fail")
+	require.ErrorIs(t, err, sss.ErrRandomness)
+}
+
+func TestDKGRandomSource(t *testing.T) {
+	run := func(args ...string) string {
+		var code int
+		out := captureStdout(t, func() {
+			code = runCLI(append([]string{"dkg", "simulate"}, args...))
+		})
+		require.Equal(t, exitOK, code)
+		return out
+	}
+	seeded := run("--insecure-deterministic-seed", "dkg", "2", "3")
+	require.Equal(t, seeded, run("--insecure-deterministic-seed", "dkg", "2", "3"))
+	require.NotEqual(t, seeded, run("2", "3"))
+
+	// Three dealers need a contribution and three salts each.
+	path := filepath.Join(t.TempDir(), "entropy")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{0x5a}, 3*(16+2*16+3*16)), 0o600))
+	require.Equal(t, run("--entropy-file", path, "--length", "16", "2", "3"), run("--entropy-file", path, "--length", "16", "2", "3"))
+	require.Equal(t, exitRandomness, runCLI([]string{"dkg", "simulate", "--entropy-file", path, "2", "3"}))
+}
+
+func TestDKGFileExchange(t *testing.T) {
+	dir := t.TempDir()
+	threshold, participants := 2, 3
+
+	// Each dealer keeps its sub-shares in a private outbox, and each
+	// recipient collects the ones dealt to it in a private inbox.
+	inboxes := make([]string, participants)
+	for i := range inboxes {
+		inboxes[i] = t.TempDir()
+	}
+	for dealer := 1; dealer <= participants; dealer++ {
+		outbox := t.TempDir()
+		require.NoError(t, writeDKGDeal(rand.Reader, dir, outbox, dealer, threshold, participants, 32))
+		for recipient := 1; recipient <= participants; recipient++ {
+			name := filepath.Base(dkgSubSharePath("", dealer, recipient))
+			data, err := os.ReadFile(filepath.Join(dkgRecipientDir(outbox, recipient), name))
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filepath.Join(inboxes[recipient-1], name), data, 0o600))
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, participants, "only the commitments should be in the shared directory")
+
+	shares := make([]string, participants)
+	for recipient := 1; recipient <= participants; recipient++ {
+		share, err := readDKGShare(dir, inboxes[recipient-1], recipient)
+		require.NoError(t, err, "finalizing share should not fail")
+		shares[recipient-1] = share
+	}
+
+	secret, err := restoreSecret(strings.Join(shares, ","))
+	require.NoError(t, err)
+	restored, err := restoreSecret(strings.Join(shares[1:], ","))
+	require.NoError(t, err)
+	require.Equal(t, secret.Bytes(), restored.Bytes())
+
+	var subShare dkgSubShare
+	path := dkgSubSharePath(inboxes[0], 2, 1)
+	require.NoError(t, readJSONFile(path, &subShare))
+	value, err := hex.DecodeString(subShare.Value)
+	require.NoError(t, err)
+	value[0] ^= 1
+	subShare.Value = hex.EncodeToString(value)
+	require.NoError(t, writeJSONFile(path, subShare))
+
+	_, err = readDKGShare(dir, inboxes[0], 1)
+	require.Error(t, err, "tampered sub-share should be rejected")
+	require.Equal(t, exitVerificationFailed, exitCode(err))
+
+	require.NoError(t, os.Remove(path))
+	_, err = readDKGShare(dir, inboxes[0], 1)
+	require.Error(t, err, "missing sub-share should be rejected")
+	require.Equal(t, exitInsufficientShares, exitCode(err))
+
+	require.NoError(t, os.Remove(dkgCommitmentPath(dir, 3)))
+	_, err = readDKGShare(dir, inboxes[1], 2)
+	require.Error(t, err, "missing commitment should be rejected")
+	require.Equal(t, exitInsufficientShares, exitCode(err))
+	_, err = readDKGShare(dir, inboxes[2], 4)
+	require.Equal(t, exitInvalidParameters, exitCode(err))
+
+	// Sub-shares must never be written to or read from the shared directory.
+	require.Equal(t, exitUsage, exitCode(writeDKGDeal(rand.Reader, dir, dir, 1, threshold, participants, 32)))
+	require.Equal(t, exitUsage, exitCode(writeDKGDeal(rand.Reader, dir, "", 1, threshold, participants, 32)))
+	_, err = readDKGShare(dir, dir, 1)
+	require.Equal(t, exitUsage, exitCode(err))
+	require.Equal(t, exitUsage, runCLI([]string{"dkg", "deal", dir, "1", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"dkg", "finalize", "--inbox", dir, dir, "1"}))
+}
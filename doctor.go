@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+	"golang.org/x/term"
+)
+
+// The doctor command checks the machine against the operational security
+// advice in the README before a split or restore ceremony. Every check is a
+// best-effort look from inside the process; a pass is not a guarantee.
+
+const (
+	doctorPass = "PASS"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+type doctorResult struct {
+	Check  string
+	Status string
+	Detail string
+}
+
+// doctorInterface is the part of a network interface the doctor looks at.
+type doctorInterface struct {
+	Name      string
+	Up        bool
+	Loopback  bool
+	Addresses int
+}
+
+func listInterfaces() ([]doctorInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]doctorInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, doctorInterface{
+			Name:      iface.Name,
+			Up:        iface.Flags&net.FlagUp != 0,
+			Loopback:  iface.Flags&net.FlagLoopback != 0,
+			Addresses: len(addrs),
+		})
+	}
+	return out, nil
+}
+
+// checkNetwork fails when an interface other than loopback is up and has an
+// address, since the machine is then not airgapped.
+func checkNetwork(ifaces []doctorInterface) doctorResult {
+	var connected, up []string
+	for _, iface := range ifaces {
+		if !iface.Up || iface.Loopback {
+			continue
+		}
+		if iface.Addresses > 0 {
+			connected = append(connected, iface.Name)
+		} else {
+			up = append(up, iface.Name)
+		}
+	}
+	switch {
+	case len(connected) > 0:
+		return doctorResult{"network", doctorFail, "interfaces with addresses: " + strings.Join(connected, ", ")}
+	case len(up) > 0:
+		return doctorResult{"network", doctorWarn, "interfaces up without addresses: " + strings.Join(up, ", ")}
+	default:
+		return doctorResult{"network", doctorPass, "no active interfaces besides loopback"}
+	}
+}
+
+// checkHistory looks at the history variables the shell exported. Shells
+// rarely export them, so an unknown state is reported as a warning.
+func checkHistory(getenv func(string) string) doctorResult {
+	if file := getenv("HISTFILE"); file != "" {
+		return doctorResult{"history", doctorWarn, "shell history is saved to " + file + ", run `unset HISTFILE; set +o history`"}
+	}
+	if getenv("HISTSIZE") == "0" || getenv("SAVEHIST") == "0" {
+		return doctorResult{"history", doctorPass, "shell history is disabled"}
+	}
+	return doctorResult{"history", doctorWarn, "cannot tell whether the shell records history, run `unset HISTFILE; set +o history` to be sure"}
+}
+
+// checkContainer looks for the marker files and cgroup names left by common
+// container runtimes under root.
+func checkContainer(root string, getenv func(string) string) doctorResult {
+	warn := func(reason string) doctorResult {
+		return doctorResult{"container", doctorWarn, "running in a container (" + reason + "), its runtime may record arguments and output"}
+	}
+	for _, marker := range []string{".dockerenv", "run/.containerenv"} {
+		if _, err := os.Stat(filepath.Join(root, marker)); err == nil {
+			return warn("/" + marker)
+		}
+	}
+	if runtime := getenv("container"); runtime != "" {
+		return warn("container=" + runtime)
+	}
+	if cgroup, err := os.ReadFile(filepath.Join(root, "proc/1/cgroup")); err == nil {
+		for _, name := range []string{"docker", "kubepods", "containerd", "lxc", "libpod"} {
+			if strings.Contains(string(cgroup), name) {
+				return warn(name + " cgroup")
+			}
+		}
+	}
+	return doctorResult{"container", doctorPass, "no container detected"}
+}
+
+// checkSwap reads the contents of /proc/swaps.
+func checkSwap(swaps string) doctorResult {
+	lines := strings.Split(strings.TrimSpace(swaps), "\n")
+	var devices []string
+	for _, line := range lines[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			devices = append(devices, fields[0])
+		}
+	}
+	if len(devices) > 0 {
+		return doctorResult{"swap", doctorWarn, "swap is enabled on " + strings.Join(devices, ", ") + ", unlocked memory may be written to disk"}
+	}
+	return doctorResult{"swap", doctorPass, "swap is disabled"}
+}
+
+// checkCoreDumps reports whether a crash would write process memory to disk.
+func checkCoreDumps(limit uint64, pattern string) doctorResult {
+	if limit == 0 {
+		return doctorResult{"core dumps", doctorPass, "core dumps are disabled"}
+	}
+	pattern = strings.TrimSpace(pattern)
+	return doctorResult{"core dumps", doctorWarn, fmt.Sprintf("core dumps are enabled (pattern %q), run `ulimit -c 0` or use --harden", pattern)}
+}
+
+func checkTerminal(stdin, stdout bool) doctorResult {
+	switch {
+	case stdin && stdout:
+		return doctorResult{"terminal", doctorPass, "stdin and stdout are a terminal"}
+	case !stdout:
+		return doctorResult{"terminal", doctorWarn, "stdout is not a terminal, output may be captured by a pipe or file"}
+	default:
+		return doctorResult{"terminal", doctorWarn, "stdin is not a terminal, prompts cannot hide typed input"}
+	}
+}
+
+// doctorSummary returns the worst status and a one-line summary.
+func doctorSummary(results []doctorResult) (string, string) {
+	var warnings, failures int
+	for _, result := range results {
+		switch result.Status {
+		case doctorWarn:
+			warnings++
+		case doctorFail:
+			failures++
+		}
+	}
+	status := doctorPass
+	if warnings > 0 {
+		status = doctorWarn
+	}
+	if failures > 0 {
+		status = doctorFail
+	}
+	return status, fmt.Sprintf("Summary: %s (%d failed, %d warnings, %d checks)", status, failures, warnings, len(results))
+}
+
+func runDoctorChecks() []doctorResult {
+	var results []doctorResult
+	if ifaces, err := listInterfaces(); err != nil {
+		results = append(results, doctorResult{"network", doctorWarn, fmt.Sprintf("cannot list interfaces: %v", err)})
+	} else {
+		results = append(results, checkNetwork(ifaces))
+	}
+	results = append(results, checkHistory(os.Getenv))
+	results = append(results, platformDoctorChecks()...)
+	results = append(results, checkTerminal(term.IsTerminal(int(os.Stdin.Fd())), term.IsTerminal(int(os.Stdout.Fd()))))
+	return results
+}
+
+func doctorCommand(flags *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		if len(args) != 0 {
+			return failWith(errUsage, "doctor takes no arguments")
+		}
+
+		results := runDoctorChecks()
+		for _, result := range results {
+			fmt.Printf("%s  %-10s  %s\n", result.Status, result.Check, result.Detail)
+		}
+		status, summary := doctorSummary(results)
+		fmt.Println(summary)
+		if status == doctorFail {
+			return exitCode(sss.ErrVerificationFailed)
+		}
+		return exitOK
+	}
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func platformDoctorChecks() []doctorResult {
+	results := []doctorResult{checkContainer("/", os.Getenv)}
+
+	if swaps, err := os.ReadFile("/proc/swaps"); err != nil {
+		results = append(results, doctorResult{"swap", doctorWarn, fmt.Sprintf("cannot read /proc/swaps: %v", err)})
+	} else {
+		results = append(results, checkSwap(string(swaps)))
+	}
+
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_CORE, &limit); err != nil {
+		results = append(results, doctorResult{"core dumps", doctorWarn, fmt.Sprintf("cannot read core dump limit: %v", err)})
+	} else {
+		pattern, _ := os.ReadFile("/proc/sys/kernel/core_pattern")
+		results = append(results, checkCoreDumps(limit.Cur, string(pattern)))
+	}
+	return results
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package main
+
+import (
+	"os"
+	"runtime"
+)
+
+func platformDoctorChecks() []doctorResult {
+	unsupported := "cannot check on " + runtime.GOOS + ", verify manually"
+	return []doctorResult{
+		checkContainer("/", os.Getenv),
+		{"swap", doctorWarn, unsupported},
+		{"core dumps", doctorWarn, unsupported},
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckNetwork(t *testing.T) {
+	loopback := doctorInterface{Name: "lo", Up: true, Loopback: true, Addresses: 2}
+
+	require.Equal(t, doctorPass, checkNetwork([]doctorInterface{loopback, {Name: "eth0"}}).Status)
+	require.Equal(t, doctorWarn, checkNetwork([]doctorInterface{loopback, {Name: "eth0", Up: true}}).Status)
+
+	result := checkNetwork([]doctorInterface{loopback, {Name: "wlan0", Up: true, Addresses: 1}})
+	require.Equal(t, doctorFail, result.Status, "connected interface should fail")
+	require.Contains(t, result.Detail, "wlan0")
+}
+
+func TestCheckHistory(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	require.Equal(t, doctorWarn, checkHistory(env(map[string]string{"HISTFILE": "/root/.bash_history"})).Status)
+	require.Equal(t, doctorPass, checkHistory(env(map[string]string{"HISTSIZE": "0"})).Status)
+	require.Equal(t, doctorWarn, checkHistory(env(nil)).Status, "unknown history state should warn")
+}
+
+func TestCheckContainer(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	root := t.TempDir()
+	require.Equal(t, doctorPass, checkContainer(root, noEnv).Status)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "proc/1"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "proc/1/cgroup"), []byte("0::/kubepods/besteffort/pod1\n"), 0o644))
+	require.Equal(t, doctorWarn, checkContainer(root, noEnv).Status, "container cgroup should warn")
+
+	root = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".dockerenv"), nil, 0o644))
+	require.Equal(t, doctorWarn, checkContainer(root, noEnv).Status, "docker marker should warn")
+
+	require.Equal(t, doctorWarn, checkContainer(t.TempDir(), func(string) string { return "podman" }).Status)
+}
+
+func TestCheckSwapAndCoreDumps(t *testing.T) {
+	header := "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n"
+	require.Equal(t, doctorPass, checkSwap(header).Status)
+
+	result := checkSwap(header + "/dev/sda2  partition  8388604  0  -2\n")
+	require.Equal(t, doctorWarn, result.Status, "enabled swap should warn")
+	require.Contains(t, result.Detail, "/dev/sda2")
+
+	require.Equal(t, doctorPass, checkCoreDumps(0, "core\n").Status)
+	require.Equal(t, doctorWarn, checkCoreDumps(1<<20, "|/usr/lib/systemd/systemd-coredump %P\n").Status)
+}
+
+func TestDoctorSummary(t *testing.T) {
+	status, summary := doctorSummary([]doctorResult{{Status: doctorPass}, {Status: doctorPass}})
+	require.Equal(t, doctorPass, status)
+	require.Contains(t, summary, "0 failed, 0 warnings")
+
+	status, _ = doctorSummary([]doctorResult{{Status: doctorPass}, {Status: doctorWarn}})
+	require.Equal(t, doctorWarn, status)
+
+	status, summary = doctorSummary([]doctorResult{{Status: doctorFail}, {Status: doctorWarn}})
+	require.Equal(t, doctorFail, status, "any failure should fail the summary")
+	require.Contains(t, summary, "1 failed, 1 warnings")
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// A shareEncoding writes shares down in one way: as "N-hex", base32, words
+// or an armored block. restore detects the encoding of every share on its
+// own, so shares written in any mix of encodings combine. A new encoding
+// only needs to implement the interface and be listed in shareEncodings,
+// and in newShareFormats to be written by split. Codex32 and ssss are not
+// among them: their shares are split over other fields and cannot be mixed
+// with the others.
+type shareEncoding interface {
+	// name is the value of split --format and the encoding in JSON output.
+	name() string
+	// detect reports whether text is meant to be a share in this encoding,
+	// even a mistyped one, so that decode can explain what is wrong.
+	detect(text string) bool
+	encode(share shareRecord) (string, error)
+	decode(text string) (shareRecord, error)
+}
+
+// shareRecord is a decoded share. Only the armor keeps the details of the
+// set; the other encodings leave them empty.
+type shareRecord struct {
+	Index int
+	Mode  string
+	Data  []byte
+
+	SetID     string
+	Threshold int
+	Holder    string
+	Comment   string
+}
+
+// shareEncodings lists the encodings in the order detection tries them;
+// base32 comes last as it accepts the widest range of text.
+var shareEncodings = []shareEncoding{
+	armorEncoding{},
+	hexEncoding{},
+	wordsEncoding{},
+	vaultEncoding{},
+	base32Encoding{},
+}
+
+// detectShareEncoding returns the encoding text is written in.
+func detectShareEncoding(text string) (shareEncoding, error) {
+	for _, e := range shareEncodings {
+		if e.detect(text) {
+			return e, nil
+		}
+	}
+	return nil, sss.NewError(sss.ErrMalformedShare, "unrecognized share encoding", nil)
+}
+
+// decodeShareRecord detects the encoding of a share and decodes it. A share
+// must hold at least one secret byte besides its x coordinate.
+func decodeShareRecord(text string) (shareEncoding, shareRecord, error) {
+	e, err := detectShareEncoding(text)
+	if err != nil {
+		return nil, shareRecord{}, err
+	}
+	share, err := e.decode(text)
+	if err == nil && len(share.Data) <= sss.ShareOverhead {
+		clear(share.Data)
+		return nil, shareRecord{}, sss.NewError(sss.ErrMalformedShare, "share is too short to hold any secret", nil)
+	}
+	return e, share, err
+}
+
+// splitShareList separates a list of shares given on the command line or in
+// a file. Shares are separated by commas or line breaks; armored blocks are
+// kept whole.
+func splitShareList(text string) []string {
+	var shares []string
+	addPlain := func(plain string) {
+		for _, line := range strings.FieldsFunc(plain, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+			if line = strings.TrimSpace(line); line != "" {
+				shares = append(shares, line)
+			}
+		}
+	}
+	for {
+		begin := strings.Index(text, armorBegin)
+		if begin < 0 {
+			break
+		}
+		end := strings.Index(text[begin:], armorEnd)
+		if end < 0 {
+			break
+		}
+		end += begin + len(armorEnd)
+		addPlain(text[:begin])
+		shares = append(shares, text[begin:end])
+		text = text[end:]
+	}
+	addPlain(text)
+	return shares
+}
+
+// readShareFiles reads the shares in the given files, "-" meaning stdin. A
+// file holding armored blocks yields only those, so the text around them is
+// ignored, and a Vault init document yields its keys; any other file holds
+// one share per line.
+func readShareFiles(paths []string) ([]string, error) {
+	var shares []string
+	for _, path := range paths {
+		f := os.Stdin
+		if path != "-" {
+			var err error
+			if f, err = os.Open(path); err != nil {
+				return nil, err
+			}
+		}
+		text, err := io.ReadAll(f)
+		if f != os.Stdin {
+			f.Close()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		var found []string
+		if isVaultInit(text) {
+			found, err = parseVaultInit(text)
+		} else {
+			found = splitShareList(string(text))
+			if strings.Contains(string(text), armorBegin) {
+				found = slices.DeleteFunc(found, func(share string) bool { return !armorEncoding{}.detect(share) })
+			}
+		}
+		clear(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(found) == 0 {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("no shares found in %s", path), nil)
+		}
+		shares = append(shares, found...)
+	}
+	return shares, nil
+}
+
+// reencodeShares writes hex shares, as returned by splitPrepared, in another
+// encoding. The set ID, threshold and holders are kept by encodings that have
+// room for them; holders may be nil.
+func reencodeShares(e shareEncoding, encodedShares string, threshold int, holders []string) ([]string, error) {
+	setID := shareSetID(encodedShares)
+	shares := strings.Split(encodedShares, ",")
+	out := make([]string, len(shares))
+	for i, encodedShare := range shares {
+		share, err := hexEncoding{}.decode(encodedShare)
+		if err != nil {
+			return nil, err
+		}
+		share.SetID, share.Threshold = setID, threshold
+		if holders != nil {
+			share.Holder = holders[i]
+		}
+		out[i], err = e.encode(share)
+		clear(share.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// hexEncoding is the original "N-hex" and "N-mode-hex" format, which
+// shamir.py also reads when the mode is empty.
+type hexEncoding struct{}
+
+func (hexEncoding) name() string { return shareFormatHex }
+
+func (hexEncoding) detect(text string) bool { return isHexShare(text) }
+
+func (hexEncoding) encode(share shareRecord) (string, error) {
+	return encodeShare(share.Index, share.Mode, share.Data), nil
+}
+
+func (hexEncoding) decode(text string) (shareRecord, error) {
+	parts := strings.Split(text, "-")
+	share := shareRecord{Mode: shareModeRaw}
+	switch len(parts) {
+	case 2:
+	case 3:
+		share.Mode = parts[1]
+	default:
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "invalid share format", nil)
+	}
+	if base, _ := splitShareMode(share.Mode); base != shareModeRaw && base != shareModeBIP39 {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("unknown share mode %q", share.Mode), nil)
+	}
+	var err error
+	if share.Index, err = strconv.Atoi(parts[0]); err != nil {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "invalid share index", err)
+	}
+	if share.Data, err = hex.DecodeString(parts[len(parts)-1]); err != nil {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "invalid share encoding", err)
+	}
+	return share, nil
+}
+
+// isHexShare reports whether text has the "N-hex" or "N-mode-hex" layout.
+func isHexShare(text string) bool {
+	parts := strings.Split(text, "-")
+	if len(parts) != 2 && len(parts) != 3 || parts[0] == "" {
+		return false
+	}
+	for _, c := range parts[0] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Base32 and words shares carry the same binary record: a version, the mode,
+// the index, the share and the first bytes of its SHA-256.
+const (
+	recordVersion      = 1
+	recordHeaderSize   = 3
+	recordChecksumSize = 2
+)
+
+// recordModes numbers the share modes in a binary record.
+var recordModes = []string{shareModeRaw, shareModeBIP39, shareModePadded, shareModeBIP39 + shareModePadSuffix}
+
+// packShareRecord returns the binary record of a share. The caller should
+// clear it.
+func packShareRecord(share shareRecord) ([]byte, error) {
+	modeCode := slices.Index(recordModes, share.Mode)
+	if modeCode < 0 || share.Index < 1 || share.Index > 255 {
+		return nil, fmt.Errorf("cannot encode share %d with mode %q", share.Index, share.Mode)
+	}
+	data := make([]byte, 0, recordHeaderSize+len(share.Data)+recordChecksumSize)
+	data = append(data, recordVersion, byte(modeCode), byte(share.Index))
+	data = append(data, share.Data...)
+	checksum := sha256.Sum256(data)
+	return append(data, checksum[:recordChecksumSize]...), nil
+}
+
+// unpackShareRecord checks the checksum of a binary record and decodes it.
+// encoding names the encoding in errors.
+func unpackShareRecord(data []byte, encoding string) (shareRecord, error) {
+	if len(data) < recordHeaderSize+sss.ShareOverhead+1+recordChecksumSize {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, encoding+" share is too short, a group may be missing", nil)
+	}
+	body := data[:len(data)-recordChecksumSize]
+	checksum := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare(checksum[:recordChecksumSize], data[len(body):]) != 1 {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, encoding+" share checksum is invalid, check the share for typos", nil)
+	}
+	if body[0] != recordVersion {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("unsupported %s share version %d", encoding, body[0]), nil)
+	}
+	if int(body[1]) >= len(recordModes) || body[2] == 0 {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, encoding+" share has an invalid header", nil)
+	}
+	return shareRecord{Index: int(body[2]), Mode: recordModes[body[1]], Data: slices.Clone(body[recordHeaderSize:])}, nil
+}
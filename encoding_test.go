@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectShareEncoding(t *testing.T) {
+	share := shareRecord{Index: 2, Mode: shareModeBIP39, Data: []byte("some share bytes")}
+	encoded := make(map[string]string)
+	for _, e := range shareEncodings {
+		if e.name() == shareFormatVault {
+			// Vault keys carry no mode or index, see vault_test.go.
+			continue
+		}
+		text, err := e.encode(share)
+		require.NoError(t, err)
+		encoded[e.name()] = text
+	}
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{encoded[shareFormatHex], shareFormatHex},
+		{"3-0a0b", shareFormatHex},
+		{encoded[shareFormatBase32], shareFormatBase32},
+		{strings.ToLower(strings.ReplaceAll(encoded[shareFormatBase32], "-", " ")), shareFormatBase32},
+		{encoded[shareFormatWords], shareFormatWords},
+		{strings.ToUpper(encoded[shareFormatWords]), shareFormatWords},
+		{encoded[shareFormatArmor], shareFormatArmor},
+		{"\n  " + encoded[shareFormatArmor], shareFormatArmor},
+		{"ABCU-0000", shareFormatBase32},
+		{"share: {1}", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		e, err := detectShareEncoding(tt.text)
+		if tt.expected == "" {
+			require.ErrorContains(t, err, "unrecognized share encoding")
+			require.Equal(t, exitMalformedShare, exitCode(err))
+			continue
+		}
+		require.NoError(t, err, tt.text)
+		require.Equal(t, tt.expected, e.name(), tt.text)
+	}
+
+	for name, text := range encoded {
+		e, decoded, err := decodeShareRecord(text)
+		require.NoError(t, err, name)
+		require.Equal(t, name, e.name())
+		require.Equal(t, share.Index, decoded.Index, name)
+		require.Equal(t, share.Mode, decoded.Mode, name)
+		require.Equal(t, share.Data, decoded.Data, name)
+	}
+}
+
+func TestSplitShareList(t *testing.T) {
+	block := encodeArmoredShare(shareRecord{SetID: "ABCD-EF01", Index: 1, Threshold: 2, Comment: "safe, top shelf", Data: []byte("some share bytes")})
+	list := splitShareList("1-0a0b, 2-0c0d\n" + block + "abandon ability able,\r\n\n")
+	require.Equal(t, []string{"1-0a0b", "2-0c0d", strings.TrimSuffix(block, "\n"), "abandon ability able"}, list)
+	require.Empty(t, splitShareList(" ,\n"))
+}
+
+func TestRestoreMixedEncodings(t *testing.T) {
+	encodedShares, _, err := splitPrepared(deterministicReader("mixed"), []byte("my_secret"), secretTypeText, false, "", 4, 3)
+	require.NoError(t, err)
+	hexShares := strings.Split(encodedShares, ",")
+	armored, err := reencodeShares(armorEncoding{comment: "kept in a safe, top shelf"}, encodedShares, 3, nil)
+	require.NoError(t, err)
+	base32, err := reencodeShares(base32Encoding{}, encodedShares, 3, nil)
+	require.NoError(t, err)
+	words, err := reencodeShares(wordsEncoding{}, encodedShares, 3, nil)
+	require.NoError(t, err)
+
+	for _, shares := range [][]string{
+		{hexShares[0], armored[1], base32[2]},
+		{words[3], hexShares[1], base32[0]},
+		{armored[0], words[1], armored[3]},
+	} {
+		var code int
+		out := captureStdout(t, func() {
+			code = runCLI([]string{"restore", "--shares", strings.Join(shares, ",")})
+		})
+		require.Equal(t, exitOK, code, shares)
+		require.Equal(t, "my_secret\n", out)
+	}
+
+	// Armored shares still require their threshold when mixed.
+	require.Equal(t, exitInsufficientShares, runCLI([]string{"restore", "--shares", armored[0] + "," + words[1]}))
+
+	// Shares from a file, one per line.
+	path := filepath.Join(t.TempDir(), "shares.txt")
+	require.NoError(t, os.WriteFile(path, []byte(words[0]+"\n"+base32[1]+"\n\n"), 0o600))
+	out := captureStdout(t, func() {
+		require.Equal(t, exitOK, runCLI([]string{"restore", "--from-file", path, hexShares[3]}))
+	})
+	require.Equal(t, "my_secret\n", out)
+}
+
+func TestSplitFormats(t *testing.T) {
+	for _, format := range shareFormatNames() {
+		var code int
+		out := captureStdout(t, func() {
+			code = runCLI([]string{"split", "--output", "json", "--format", format, "a sixteen byte secret", "2", "3"})
+		})
+		require.Equal(t, exitOK, code, format)
+		require.Contains(t, out, `"encoding": "`+format+`"`)
+	}
+
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "words", "my_secret", "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 3)
+	for i := range lines {
+		_, lines[i], _ = strings.Cut(lines[i], ": ")
+	}
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", lines[2] + "\n" + lines[0]})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "yaml", "my_secret", "2", "3"}))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Exit codes are part of the command line interface and stay stable across
+// releases, so scripts can react to a failure class without parsing
+// messages. Each class except usage corresponds to an sss error kind.
+const (
+	exitOK                 = 0
+	exitError              = 1 // anything not covered below, such as I/O errors
+	exitUsage              = 2 // unknown command, bad flags or arguments
+	exitInvalidParameters  = 3 // threshold or share count out of range
+	exitInvalidSecret      = 4 // the secret given to split is rejected
+	exitMalformedShare     = 5 // a share cannot be decoded or is duplicated
+	exitInconsistentShares = 6 // shares cannot come from the same split
+	exitInsufficientShares = 7 // too few or wrong shares, detected on restore
+	exitVerificationFailed = 8 // a fingerprint or other check did not match
+	exitRandomness         = 9 // the random source failed or ran out
+)
+
+// errUsage classifies command line mistakes, which only exist in the CLI.
+var errUsage = errors.New("usage error")
+
+// exitCode maps an error to its exit code. The outermost *sss.Error decides
+// the class, so the CLI can reclassify a library error by wrapping it.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	kind := err
+	var sssErr *sss.Error
+	if errors.As(err, &sssErr) {
+		kind = sssErr.Kind
+	}
+	switch kind {
+	case errUsage:
+		return exitUsage
+	case sss.ErrInvalidParameters:
+		return exitInvalidParameters
+	case sss.ErrInvalidSecret:
+		return exitInvalidSecret
+	case sss.ErrMalformedShare, sss.ErrDuplicateShare:
+		return exitMalformedShare
+	case sss.ErrInconsistentShares:
+		return exitInconsistentShares
+	case sss.ErrInsufficientShares:
+		return exitInsufficientShares
+	case sss.ErrVerificationFailed:
+		return exitVerificationFailed
+	case sss.ErrRandomness:
+		return exitRandomness
+	default:
+		return exitError
+	}
+}
+
+// fail writes msg and err to stderr and returns the exit code for err.
+func fail(msg string, err error) int {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", msg, err)
+	return exitCode(err)
+}
+
+// failWith writes msg to stderr and returns the exit code for kind.
+func failWith(kind error, msg string) int {
+	fmt.Fprintln(os.Stderr, msg)
+	return exitCode(kind)
+}
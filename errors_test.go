@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tofel/shamir/sss"
+)
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, exitOK, exitCode(nil))
+	require.Equal(t, exitError, exitCode(errors.New("disk full")))
+	require.Equal(t, exitUsage, exitCode(errUsage))
+	require.Equal(t, exitMalformedShare, exitCode(fmt.Errorf("restore: %w", sss.NewError(sss.ErrDuplicateShare, "duplicate part detected", nil))))
+	require.Equal(t, exitRandomness, exitCode(sss.NewError(sss.ErrRandomness, "entropy file exhausted", io.ErrUnexpectedEOF)))
+
+	inner := sss.NewError(sss.ErrInvalidSecret, "mnemonic checksum is invalid", nil)
+	outer := sss.NewError(sss.ErrInsufficientShares, "validation failed", inner)
+	require.Equal(t, exitInsufficientShares, exitCode(outer), "outermost class should win")
+}
+
+func TestRestoreExitCodes(t *testing.T) {
+	encodedShares, _, err := splitPrepared(rand.Reader, []byte(testMnemonic), secretTypeText, false, "", 5, 3)
+	require.NoError(t, err)
+	compactShares, _, err := splitPrepared(rand.Reader, []byte(testMnemonic), secretTypeBIP39, true, "", 5, 3)
+	require.NoError(t, err)
+	shares := strings.Split(encodedShares, ",")
+
+	type testCase struct {
+		name     string
+		args     []string
+		expected int
+	}
+
+	testCases := []testCase{
+		{name: "missing shares", args: nil, expected: exitUsage},
+		{name: "malformed share", args: []string{"1-zz,2-zz"}, expected: exitMalformedShare},
+		{name: "duplicate share", args: []string{shares[0] + "," + shares[0]}, expected: exitMalformedShare},
+		{name: "different lengths", args: []string{shares[0] + ",2-00ff"}, expected: exitInconsistentShares},
+		{name: "insufficient mnemonic shares", args: []string{"--type", "bip39", getNshares(encodedShares, 2)}, expected: exitInsufficientShares},
+		{name: "insufficient compact shares", args: []string{getNshares(compactShares, 2)}, expected: exitInsufficientShares},
+		{name: "invalid fingerprint", args: []string{"--verify-only", "--fingerprint", "sfp1:00:00", encodedShares}, expected: exitInvalidParameters},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, runCLI(append([]string{"restore"}, tc.args...)))
+		})
+	}
+}
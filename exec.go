@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Ways restore --exec can hand the restored secret to the child process.
+const (
+	execViaStdin = "stdin"
+	execViaFD    = "fd"
+	execViaEnv   = "env"
+)
+
+const (
+	execDefaultEnv = "SHAMIR_SECRET"
+	// execSecretFDEnv tells the child which descriptor to read in fd mode.
+	execSecretFDEnv = "SHAMIR_SECRET_FD"
+	// execSecretFD is the first descriptor after stdin, stdout and stderr,
+	// which is where exec.Cmd places ExtraFiles[0].
+	execSecretFD = 3
+)
+
+// runWithSecret runs command through /bin/sh and passes it secret on stdin,
+// on a pipe descriptor or in an environment variable, so the secret never
+// goes through our stdout. secret is wiped as soon as it has been written to
+// the pipe or copied into the environment, not when the child exits. The
+// child's exit status is returned as an *exec.ExitError.
+func runWithSecret(command string, secret []byte, via string, envName string) error {
+	// Wipes the secret on the paths that never hand it over.
+	defer clear(secret)
+
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	var pipe io.WriteCloser
+	var childEnd *os.File
+	switch via {
+	case execViaStdin:
+		var err error
+		cmd.Stdin = nil
+		pipe, err = cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+	case execViaFD:
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		pipe, childEnd = w, r
+		cmd.ExtraFiles = []*os.File{r}
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", execSecretFDEnv, execSecretFD))
+	case execViaEnv:
+		if envName == "" {
+			return fmt.Errorf("environment variable name cannot be empty")
+		}
+		// The environment block is built from Go strings, so this copy
+		// cannot be wiped; it lives until the process exits.
+		cmd.Env = append(os.Environ(), envName+"="+string(secret))
+		clear(secret)
+	default:
+		return fmt.Errorf("unknown delivery method %q, use %q, %q or %q", via, execViaStdin, execViaFD, execViaEnv)
+	}
+
+	err := cmd.Start()
+	if childEnd != nil {
+		childEnd.Close()
+	}
+	if err != nil {
+		if pipe != nil {
+			pipe.Close()
+		}
+		return err
+	}
+
+	writeErr := make(chan error, 1)
+	if pipe != nil {
+		// Writing happens in the background so a child that does not read
+		// the whole secret cannot block us from waiting on it.
+		go func() {
+			_, err := pipe.Write(secret)
+			if closeErr := pipe.Close(); err == nil {
+				err = closeErr
+			}
+			clear(secret)
+			writeErr <- err
+		}()
+	} else {
+		writeErr <- nil
+	}
+
+	err = cmd.Wait()
+	werr := <-writeErr
+	// A child that succeeds without reading the whole secret closes the
+	// pipe under us; only its exit status matters then.
+	if errors.Is(werr, syscall.EPIPE) || errors.Is(werr, os.ErrClosed) {
+		werr = nil
+	}
+	if err == nil && werr != nil {
+		return fmt.Errorf("failed to pass secret to child: %w", werr)
+	}
+	return err
+}
+
+// childExitCode returns the exit code restore --exec passes on for the
+// child's exit status. A child killed by a signal has no exit code, so it
+// maps to 128 plus the signal number, as shells report it.
+func childExitCode(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithSecret(t *testing.T) {
+	type testCase struct {
+		name    string
+		via     string
+		command string
+	}
+
+	testCases := []testCase{
+		{
+			name:    "stdin",
+			via:     execViaStdin,
+			command: `cat > "$OUT"`,
+		},
+		{
+			name:    "pipe descriptor",
+			via:     execViaFD,
+			command: `cat <&"$SHAMIR_SECRET_FD" > "$OUT"`,
+		},
+		{
+			name:    "environment",
+			via:     execViaEnv,
+			command: `printf %s "$SHAMIR_SECRET" > "$OUT"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "secret")
+			t.Setenv("OUT", out)
+
+			secret := []byte("my_secret")
+			err := runWithSecret(tc.command, secret, tc.via, execDefaultEnv)
+			require.NoError(t, err, "running child should not fail")
+			require.Equal(t, make([]byte, len(secret)), secret, "secret should be wiped after handing it over")
+
+			received, err := os.ReadFile(out)
+			require.NoError(t, err)
+			require.Equal(t, "my_secret", string(received), "child should receive the secret")
+		})
+	}
+}
+
+func TestRunWithSecretErrors(t *testing.T) {
+	err := runWithSecret("exit 3", []byte("my_secret"), execViaEnv, execDefaultEnv)
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "child failure should be reported as exit error")
+	require.Equal(t, 3, exitErr.ExitCode())
+	require.Equal(t, 3, childExitCode(exitErr))
+
+	err = runWithSecret("kill -TERM $$", []byte("my_secret"), execViaEnv, execDefaultEnv)
+	require.True(t, errors.As(err, &exitErr), "a child killed by a signal should be reported as exit error")
+	require.Equal(t, 128+int(syscall.SIGTERM), childExitCode(exitErr))
+
+	err = runWithSecret("true", []byte("my_secret"), "argv", execDefaultEnv)
+	require.Error(t, err, "unknown delivery method should fail")
+
+	err = runWithSecret("true", []byte("my_secret"), execViaEnv, "")
+	require.Error(t, err, "empty environment variable name should fail")
+}
+
+func TestRunWithSecretWipesOnHandover(t *testing.T) {
+	dir := t.TempDir()
+	done := filepath.Join(dir, "done")
+	t.Setenv("DONE", done)
+
+	// The child keeps running after reading the secret until done exists.
+	secret := []byte("my_secret")
+	result := make(chan error, 1)
+	go func() {
+		result <- runWithSecret(`cat > /dev/null; while [ ! -e "$DONE" ]; do sleep 0.01; done`, secret, execViaStdin, execDefaultEnv)
+	}()
+	require.Eventually(t, func() bool {
+		return bytes.Equal(secret, make([]byte, len(secret)))
+	}, 5*time.Second, 10*time.Millisecond, "secret should be wiped while the child still runs")
+	require.NoError(t, os.WriteFile(done, nil, 0o600))
+	require.NoError(t, <-result)
+}
+
+func TestRunWithSecretUnreadStdin(t *testing.T) {
+	// More than a pipe buffer, so the write fails once the child is gone.
+	secret := bytes.Repeat([]byte("s"), 1<<20)
+	require.NoError(t, runWithSecret("exit 0", secret, execViaStdin, execDefaultEnv), "a child that ignores the secret and succeeds is not an error")
+	require.Equal(t, make([]byte, len(secret)), secret)
+
+	err := runWithSecret("exit 2", bytes.Repeat([]byte("s"), 1<<20), execViaStdin, execDefaultEnv)
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "the child's failure is still reported")
+	require.Equal(t, 2, exitErr.ExitCode())
+}
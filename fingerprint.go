@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Salted secret fingerprints let a quorum confirm, for example in a yearly
+// drill, that its shares still restore the right secret without showing it.
+// The fingerprint is usually stored next to the shares and the secret may be
+// guessable, so it is derived with a deliberately slow KDF.
+
+const (
+	fingerprintPrefix     = "sfp1"
+	fingerprintSaltSize   = 16
+	fingerprintIterations = 600000
+	fingerprintDigestSize = 16
+)
+
+// secretFingerprint returns a fingerprint of secret with a fresh salt read
+// from random.
+func secretFingerprint(random io.Reader, secret []byte) (string, error) {
+	salt := make([]byte, fingerprintSaltSize)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	digest := fingerprintDigest(secret, salt)
+	return fmt.Sprintf("%s:%s:%s", fingerprintPrefix, hex.EncodeToString(salt), hex.EncodeToString(digest)), nil
+}
+
+// matchFingerprint reports whether secret has the given fingerprint.
+func matchFingerprint(secret []byte, fingerprint string) (bool, error) {
+	parts := strings.Split(strings.TrimSpace(fingerprint), ":")
+	if len(parts) != 3 || parts[0] != fingerprintPrefix {
+		return false, sss.NewError(sss.ErrInvalidParameters, "invalid fingerprint format", nil)
+	}
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil || len(salt) != fingerprintSaltSize {
+		return false, sss.NewError(sss.ErrInvalidParameters, "invalid fingerprint salt", nil)
+	}
+	expected, err := hex.DecodeString(parts[2])
+	if err != nil || len(expected) != fingerprintDigestSize {
+		return false, sss.NewError(sss.ErrInvalidParameters, "invalid fingerprint digest", nil)
+	}
+
+	return subtle.ConstantTimeCompare(fingerprintDigest(secret, salt), expected) == 1, nil
+}
+
+func fingerprintDigest(secret []byte, salt []byte) []byte {
+	return pbkdf2.Key(secret, salt, fingerprintIterations, fingerprintDigestSize, sha256.New)
+}
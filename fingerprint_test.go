@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretFingerprint(t *testing.T) {
+	secret := []byte("my_secret")
+
+	fingerprint, err := secretFingerprint(rand.Reader, secret)
+	require.NoError(t, err, "computing fingerprint should not fail")
+	require.True(t, strings.HasPrefix(fingerprint, fingerprintPrefix+":"))
+	require.NotContains(t, fingerprint, string(secret))
+
+	other, err := secretFingerprint(rand.Reader, secret)
+	require.NoError(t, err)
+	require.NotEqual(t, fingerprint, other, "fingerprints of the same secret should be salted")
+
+	matches, err := matchFingerprint(secret, fingerprint)
+	require.NoError(t, err)
+	require.True(t, matches, "fingerprint should match its secret")
+
+	matches, err = matchFingerprint([]byte("my_secreT"), fingerprint)
+	require.NoError(t, err)
+	require.False(t, matches, "fingerprint should not match a different secret")
+
+	encodedShares, _, err := splitPrepared(rand.Reader, secret, secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+	restored, err := restoreSecret(getNshares(encodedShares, 2))
+	require.NoError(t, err)
+	matches, err = matchFingerprint(restored.Bytes(), fingerprint)
+	require.NoError(t, err)
+	require.True(t, matches, "fingerprint should match the restored secret")
+
+	for _, invalid := range []string{
+		"",
+		"sfp2:00:00",
+		fingerprintPrefix + ":zz:00",
+		fingerprintPrefix + ":" + strings.Repeat("00", fingerprintSaltSize) + ":00",
+	} {
+		_, err = matchFingerprint(secret, invalid)
+		require.Error(t, err, "malformed fingerprint %q should be rejected", invalid)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// A shareFormat is a value of split --format. It registers the flags only it
+// takes, refuses split options it cannot honour, splits the secret and
+// writes the shares, so that split itself knows no format. The
+// shareEncodings are formats through encodingFormat; codex32 and ssss split
+// over other fields and implement the interface themselves. A new format
+// only needs to be listed in newShareFormats.
+type shareFormat interface {
+	name() string
+	// setFlags registers the split flags that only this format takes.
+	setFlags(flags *flag.FlagSet)
+	check(opts splitOptions) error
+	split(random io.Reader, secret []byte, opts splitOptions) (splitShares, error)
+	// write prints the shares for text output.
+	write(w io.Writer, shares splitShares, opts splitOptions) error
+	// describe parses one of the encoded shares for JSON output.
+	describe(encodedShare string) (jsonShare, []byte, error)
+}
+
+// splitOptions are the split flags that every format sees.
+type splitOptions struct {
+	secretType string
+	compact    bool
+	pad        string
+	// generatedBytes is set when the secret comes from --generate bytes.
+	generatedBytes bool
+	threshold      int
+	totalShares    int
+	// holders is nil unless --holders was given.
+	holders []string
+	print   bool
+	qr      bool
+}
+
+// splitShares are the shares of one split.
+type splitShares struct {
+	// encoded holds the shares separated by commas, as sheets, QR codes and
+	// the set ID use them.
+	encoded string
+	mode    string
+	// formatted holds every share as the format writes it, or is nil when
+	// that is the encoded share.
+	formatted []string
+}
+
+// newShareFormats returns the values of split --format in the order help
+// lists them. Formats may keep the values of their flags, so every split
+// command gets its own.
+func newShareFormats() []shareFormat {
+	return []shareFormat{
+		encodingFormat{hexEncoding{}},
+		encodingFormat{base32Encoding{}},
+		encodingFormat{wordsEncoding{}},
+		encodingFormat{vaultEncoding{}},
+		encodingFormat{&armorEncoding{}},
+		&codex32Format{},
+		&ssssFormat{},
+	}
+}
+
+// shareFormatNames lists the values of split --format.
+func shareFormatNames() []string {
+	var names []string
+	for _, f := range newShareFormats() {
+		names = append(names, f.name())
+	}
+	return names
+}
+
+func shareFormatByName(formats []shareFormat, name string) (shareFormat, error) {
+	for _, f := range formats {
+		if f.name() == name {
+			return f, nil
+		}
+	}
+	return nil, sss.NewError(errUsage, fmt.Sprintf("unknown --format %q, expected one of %s", name, strings.Join(shareFormatNames(), ", ")), nil)
+}
+
+// setFormatFlags registers the flags of every format on flags and returns
+// the name of the format each flag belongs to.
+func setFormatFlags(flags *flag.FlagSet, formats []shareFormat) map[string]string {
+	owners := make(map[string]string)
+	for _, f := range formats {
+		own := flag.NewFlagSet(f.name(), flag.ContinueOnError)
+		f.setFlags(own)
+		own.VisitAll(func(fl *flag.Flag) {
+			flags.Var(fl.Value, fl.Name, fl.Usage)
+			owners[fl.Name] = f.name()
+		})
+	}
+	return owners
+}
+
+// checkFormatFlags refuses flags given for another format than chosen.
+func checkFormatFlags(flags *flag.FlagSet, owners map[string]string, chosen string) error {
+	var err error
+	flags.Visit(func(fl *flag.Flag) {
+		if owner, ok := owners[fl.Name]; ok && owner != chosen && err == nil {
+			err = sss.NewError(errUsage, fmt.Sprintf("--%s requires --format %s", fl.Name, owner), nil)
+		}
+	})
+	return err
+}
+
+// Encodings refine how encodingFormat splits with these optional interfaces.
+type (
+	splitFlagSetter interface {
+		setSplitFlags(flags *flag.FlagSet)
+	}
+	splitChecker interface {
+		checkSplit(opts splitOptions) error
+	}
+	shareWriter interface {
+		writeShares(w io.Writer, shares splitShares, opts splitOptions) error
+	}
+	// printedEncoding marks encodings made to be printed and read back,
+	// whose shares go on sheets and QR codes instead of hex shares.
+	printedEncoding interface {
+		printed()
+	}
+)
+
+// encodingFormat splits with splitPrepared and writes the shares in one of
+// the shareEncodings, so that they mix with shares in any other encoding.
+type encodingFormat struct {
+	shareEncoding
+}
+
+func (f encodingFormat) setFlags(flags *flag.FlagSet) {
+	if s, ok := f.shareEncoding.(splitFlagSetter); ok {
+		s.setSplitFlags(flags)
+	}
+}
+
+func (f encodingFormat) check(opts splitOptions) error {
+	if c, ok := f.shareEncoding.(splitChecker); ok {
+		return c.checkSplit(opts)
+	}
+	return nil
+}
+
+func (f encodingFormat) split(random io.Reader, secret []byte, opts splitOptions) (splitShares, error) {
+	encoded, mode, err := splitPrepared(random, secret, opts.secretType, opts.compact, opts.pad, opts.totalShares, opts.threshold)
+	if err != nil {
+		return splitShares{}, err
+	}
+	shares := splitShares{encoded: encoded, mode: mode}
+	if f.name() == shareFormatHex {
+		return shares, nil
+	}
+	if shares.formatted, err = reencodeShares(f.shareEncoding, encoded, opts.threshold, opts.holders); err != nil {
+		return splitShares{}, err
+	}
+	if _, ok := f.shareEncoding.(printedEncoding); ok {
+		shares.encoded = strings.Join(shares.formatted, ",")
+	}
+	return shares, nil
+}
+
+func (f encodingFormat) write(w io.Writer, shares splitShares, opts splitOptions) error {
+	if s, ok := f.shareEncoding.(shareWriter); ok {
+		return s.writeShares(w, shares, opts)
+	}
+	if shares.formatted == nil {
+		fmt.Fprintln(w, shares.encoded)
+		return nil
+	}
+	for i, share := range shares.formatted {
+		fmt.Fprintf(w, "Share %d of %d: %s\n", i+1, opts.totalShares, share)
+	}
+	return nil
+}
+
+func (encodingFormat) describe(encodedShare string) (jsonShare, []byte, error) {
+	return describeShare(encodedShare)
+}
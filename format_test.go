@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareFormatFlags(t *testing.T) {
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	owners := setFormatFlags(flags, newShareFormats())
+	require.Equal(t, map[string]string{"comment": shareFormatArmor, "codex32-id": shareFormatCodex32, "token": shareFormatSSSS}, owners)
+
+	// A flag of one format is refused with every other format.
+	values := map[string]string{"comment": "top shelf", "codex32-id": "cash", "token": "backup"}
+	for name, owner := range owners {
+		for _, format := range shareFormatNames() {
+			args := []string{"split", "--format", format, "--" + name, values[name], "a sixteen byte secret", "2", "3"}
+			var code int
+			captureStdout(t, func() { code = runCLI(args) })
+			if format == owner {
+				require.Equal(t, exitOK, code, args)
+			} else {
+				require.Equal(t, exitUsage, code, args)
+			}
+		}
+	}
+
+	_, err := shareFormatByName(newShareFormats(), "yaml")
+	require.Equal(t, exitUsage, exitCode(err))
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// split --generate creates the secret inside the tool, so it goes straight
+// into shares without ever being typed or displayed. Dice rolls can be mixed
+// in for ceremonies that do not trust the RNG of a freshly installed machine:
+// the secret is then derived from both with HKDF-SHA256, and is unpredictable
+// as long as either input is.
+
+const (
+	generateBytes = "bytes"
+	generateBIP39 = "bip39"
+
+	generateDefaultBytes = 32
+	generateMaxBytes     = 1024
+	generateDefaultWords = 24
+
+	diceSides = 6
+)
+
+// generateInfo binds the derived secret to this use of HKDF.
+const generateInfo = "shamir split --generate v1"
+
+type generateSpec struct {
+	kind string
+	size int // bytes, or words for bip39
+}
+
+func (g generateSpec) String() string {
+	return fmt.Sprintf("%s:%d", g.kind, g.size)
+}
+
+// entropySize is the number of random bytes behind the secret.
+func (g generateSpec) entropySize() int {
+	if g.kind == generateBIP39 {
+		return g.size * 4 / 3
+	}
+	return g.size
+}
+
+// generatedName describes the generated secret in JSON output, or is empty
+// when the secret was given.
+func generatedName(generate string, spec generateSpec) string {
+	if generate == "" {
+		return ""
+	}
+	return spec.String()
+}
+
+// parseGenerateSpec parses "bytes[:N]" or "bip39[:WORDS]".
+func parseGenerateSpec(spec string) (generateSpec, error) {
+	kind, sizeArg, hasSize := strings.Cut(spec, ":")
+	g := generateSpec{kind: kind}
+	switch kind {
+	case generateBytes:
+		g.size = generateDefaultBytes
+	case generateBIP39:
+		g.size = generateDefaultWords
+	default:
+		return generateSpec{}, sss.NewError(errUsage, fmt.Sprintf("unknown --generate kind %q, expected bytes or bip39", kind), nil)
+	}
+	if hasSize {
+		size, err := strconv.Atoi(sizeArg)
+		if err != nil {
+			return generateSpec{}, sss.NewError(errUsage, fmt.Sprintf("invalid --generate size %q", sizeArg), nil)
+		}
+		g.size = size
+	}
+
+	switch {
+	case g.kind == generateBytes && (g.size < 1 || g.size > generateMaxBytes):
+		return generateSpec{}, sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("generated secrets must be 1 to %d bytes", generateMaxBytes), nil)
+	case g.kind == generateBIP39 && (g.size < 12 || g.size > 24 || g.size%3 != 0):
+		return generateSpec{}, sss.NewError(sss.ErrInvalidParameters, "generated mnemonics must have 12, 15, 18, 21 or 24 words", nil)
+	}
+	return g, nil
+}
+
+// diceRollsNeeded is the number of rolls of a fair die that carry at least as
+// much entropy as size bytes.
+func diceRollsNeeded(size int) int {
+	return int(math.Ceil(float64(size*8) / math.Log2(diceSides)))
+}
+
+// parseDiceRolls returns the rolls in line as digits, ignoring whitespace.
+func parseDiceRolls(line []byte) ([]byte, error) {
+	rolls := make([]byte, 0, len(line))
+	for i, c := range line {
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c >= '1' && c <= '0'+diceSides:
+			rolls = append(rolls, c)
+		default:
+			clear(rolls)
+			return nil, fmt.Errorf("character %d is not a roll between 1 and %d", i+1, diceSides)
+		}
+	}
+	return rolls, nil
+}
+
+// readDiceRolls prompts on w until at least needed rolls were read from in,
+// one line at a time. A line with anything but rolls is rejected and can be
+// entered again. On a terminal the rolls are not echoed.
+func readDiceRolls(in io.Reader, w io.Writer, needed int) ([]byte, error) {
+	r := newLineReader(in, w)
+	rolls := make([]byte, 0, needed)
+	fmt.Fprintf(w, "Enter at least %d rolls of a %d-sided die, as digits, over one or more lines.\n", needed, diceSides)
+	for len(rolls) < needed {
+		line, err := r.prompt("Rolls (%d of %d): ", len(rolls), needed)
+		if err != nil {
+			clear(rolls)
+			if errors.Is(err, io.EOF) {
+				return nil, sss.NewError(sss.ErrRandomness, fmt.Sprintf("input ended after %d of %d dice rolls", len(rolls), needed), nil)
+			}
+			return nil, err
+		}
+
+		parsed, err := parseDiceRolls(line)
+		clear(line)
+		if err != nil {
+			fmt.Fprintf(w, "Line rejected: %v. Enter it again.\n", err)
+			continue
+		}
+		rolls = append(rolls, parsed...)
+		clear(parsed)
+	}
+	return rolls, nil
+}
+
+// generateSecret creates a secret as described by spec from random, mixing
+// in dice when it is not empty. A bip39 secret is returned as its mnemonic.
+func generateSecret(random io.Reader, spec generateSpec, dice []byte) (*secureBuffer, error) {
+	entropy, err := newSecureBuffer(spec.entropySize())
+	if err != nil {
+		return nil, err
+	}
+	defer entropy.Destroy()
+	if _, err := io.ReadFull(random, entropy.Bytes()); err != nil {
+		return nil, sss.NewError(sss.ErrRandomness, "failed to generate secret", err)
+	}
+
+	if len(dice) > 0 {
+		ikm := append(append(make([]byte, 0, len(entropy.Bytes())+len(dice)), entropy.Bytes()...), dice...)
+		mixed, err := hkdf.Key(sha256.New, ikm, nil, generateInfo, len(entropy.Bytes()))
+		clear(ikm)
+		if err != nil {
+			return nil, err
+		}
+		copy(entropy.Bytes(), mixed)
+		clear(mixed)
+	}
+
+	if spec.kind == generateBytes {
+		return newSecureBufferFrom(entropy.Bytes())
+	}
+	mnemonic, err := mnemonicFromEntropy(entropy.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer clear(mnemonic)
+	return newSecureBufferFrom(mnemonic)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGenerateSpec(t *testing.T) {
+	type testCase struct {
+		spec     string
+		expected generateSpec
+		wantErr  bool
+	}
+
+	testCases := []testCase{
+		{spec: "bytes", expected: generateSpec{kind: generateBytes, size: 32}},
+		{spec: "bytes:16", expected: generateSpec{kind: generateBytes, size: 16}},
+		{spec: "bip39", expected: generateSpec{kind: generateBIP39, size: 24}},
+		{spec: "bip39:12", expected: generateSpec{kind: generateBIP39, size: 12}},
+		{spec: "bytes:0", wantErr: true},
+		{spec: "bytes:2000", wantErr: true},
+		{spec: "bip39:13", wantErr: true},
+		{spec: "bip39:many", wantErr: true},
+		{spec: "hex", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			spec, err := parseGenerateSpec(tc.spec)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, spec)
+		})
+	}
+
+	require.Equal(t, 16, generateSpec{kind: generateBIP39, size: 12}.entropySize())
+	require.Equal(t, 32, generateSpec{kind: generateBIP39, size: 24}.entropySize())
+}
+
+func TestReadDiceRolls(t *testing.T) {
+	require.Equal(t, 50, diceRollsNeeded(16))
+	require.Equal(t, 100, diceRollsNeeded(32))
+
+	_, err := parseDiceRolls([]byte("1 2 7"))
+	require.ErrorContains(t, err, "character 5")
+
+	var prompts bytes.Buffer
+	rolls, err := readDiceRolls(strings.NewReader("12 34\n5x\n56\n6"), &prompts, 6)
+	require.NoError(t, err)
+	require.Equal(t, []byte("123456"), rolls)
+	require.Contains(t, prompts.String(), "Line rejected")
+
+	_, err = readDiceRolls(strings.NewReader("123\n"), &prompts, 6)
+	require.ErrorContains(t, err, "input ended after 3 of 6 dice rolls")
+}
+
+func TestGenerateSecret(t *testing.T) {
+	entropy := bytes.Repeat([]byte{0x42}, 32)
+
+	secret, err := generateSecret(bytes.NewReader(entropy), generateSpec{kind: generateBytes, size: 32}, nil)
+	require.NoError(t, err)
+	require.Equal(t, entropy, secret.Bytes(), "without dice the random bytes are used as is")
+	secret.Destroy()
+
+	mixed, err := generateSecret(bytes.NewReader(entropy), generateSpec{kind: generateBytes, size: 32}, []byte("123456"))
+	require.NoError(t, err)
+	defer mixed.Destroy()
+	require.Len(t, mixed.Bytes(), 32)
+	require.NotEqual(t, entropy, mixed.Bytes(), "dice should change the secret")
+
+	again, err := generateSecret(bytes.NewReader(entropy), generateSpec{kind: generateBytes, size: 32}, []byte("123456"))
+	require.NoError(t, err)
+	defer again.Destroy()
+	require.Equal(t, mixed.Bytes(), again.Bytes(), "mixing should be deterministic")
+
+	mnemonic, err := generateSecret(bytes.NewReader(entropy), generateSpec{kind: generateBIP39, size: 12}, nil)
+	require.NoError(t, err)
+	defer mnemonic.Destroy()
+	require.Len(t, strings.Fields(string(mnemonic.Bytes())), 12)
+	_, err = mnemonicEntropy(mnemonic.Bytes())
+	require.NoError(t, err, "generated mnemonic should be valid")
+
+	_, err = generateSecret(bytes.NewReader(entropy[:8]), generateSpec{kind: generateBytes, size: 32}, nil)
+	require.Error(t, err, "short randomness should fail")
+}
+
+func TestSplitGenerate(t *testing.T) {
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--generate", "bip39:12", "--compact", "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	encodedShares := strings.TrimSpace(out)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", strings.Join(strings.Split(encodedShares, ",")[1:], ",")})
+	})
+	require.Equal(t, exitOK, code)
+	require.Len(t, strings.Fields(out), 12)
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--generate", "bytes", "my_secret", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--dice", "my_secret", "2", "3"}))
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Hardened mode (--harden) closes the usual ways a secret leaks out of a
+// running process: core dumps, ptrace and /proc/<pid>/mem, and output
+// redirected into a file other users can read. It is only available on
+// Linux; --seccomp additionally blocks system calls that split and restore
+// never need.
+
+// checkPrivateOutput refuses a regular file that every user can read, which
+// is what `shamir restore ... > secret.txt` creates under the usual umask.
+func checkPrivateOutput(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to inspect output: %w", err)
+	}
+	if info.Mode().IsRegular() && info.Mode().Perm()&0o004 != 0 {
+		return fmt.Errorf("output is redirected to a world-readable file, restrict its permissions or set umask 077 first")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// hardenProcess disables core dumps and makes the process non-dumpable, which
+// also stops other processes of the same user from attaching with ptrace or
+// reading /proc/<pid>/mem. With seccomp set it installs a filter that denies
+// seccompDeniedSyscalls for the rest of the process lifetime.
+func hardenProcess(seccomp bool) error {
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{}); err != nil {
+		return fmt.Errorf("failed to disable core dumps: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to make the process non-dumpable: %w", err)
+	}
+	if err := checkPrivateOutput(os.Stdout); err != nil {
+		return err
+	}
+	if seccomp {
+		return installSeccompFilter()
+	}
+	return nil
+}
+
+// seccompDeniedSyscalls are refused with EPERM once the filter is installed:
+// no network access, no debugging of other processes and no new programs.
+var seccompDeniedSyscalls = []uint32{
+	unix.SYS_SOCKET,
+	unix.SYS_SOCKETPAIR,
+	unix.SYS_CONNECT,
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_EXECVE,
+	unix.SYS_EXECVEAT,
+}
+
+var seccompArch = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}
+
+// Offsets into struct seccomp_data.
+const (
+	seccompDataNR   = 0
+	seccompDataArch = 4
+	// x32 system calls on amd64 have this bit set and bypass the numbers
+	// in seccompDeniedSyscalls, so they are refused altogether.
+	seccompX32SyscallBit = 0x40000000
+)
+
+// buildSeccompFilter returns a BPF program that kills the process on a
+// foreign architecture, returns EPERM for the denied system calls and allows
+// everything else.
+func buildSeccompFilter(arch uint32, denied []uint32) []unix.SockFilter {
+	deny := 6 + len(denied)
+	jumpToDeny := func(pc int) uint8 { return uint8(deny - pc - 1) }
+
+	filter := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: seccompDataArch},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 1, K: arch},
+		{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_KILL_PROCESS},
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: seccompDataNR},
+		{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, Jt: jumpToDeny(4), K: seccompX32SyscallBit},
+	}
+	for _, nr := range denied {
+		filter = append(filter, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: jumpToDeny(len(filter)), K: nr})
+	}
+	return append(filter,
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ALLOW},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)},
+	)
+}
+
+func installSeccompFilter() error {
+	arch, ok := seccompArch[runtime.GOARCH]
+	if !ok {
+		return fmt.Errorf("seccomp filter is not supported on %s", runtime.GOARCH)
+	}
+	filter := buildSeccompFilter(arch, seccompDeniedSyscalls)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	// no_new_privs is per thread; TSYNC copies it to the other threads along
+	// with the filter, as long as both are set from the same thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	thread, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(&prog)))
+	if errno != 0 {
+		return fmt.Errorf("failed to install seccomp filter: %w", errno)
+	}
+	if thread != 0 {
+		return fmt.Errorf("failed to install seccomp filter on thread %d", thread)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// runSeccompFilter interprets the subset of BPF used by buildSeccompFilter.
+func runSeccompFilter(t *testing.T, filter []unix.SockFilter, arch, nr uint32) uint32 {
+	var acc uint32
+	for pc := 0; pc < len(filter); pc++ {
+		ins := filter[pc]
+		switch ins.Code {
+		case unix.BPF_LD | unix.BPF_W | unix.BPF_ABS:
+			acc = nr
+			if ins.K == seccompDataArch {
+				acc = arch
+			}
+		case unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K:
+			if acc == ins.K {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		case unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K:
+			if acc >= ins.K {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		case unix.BPF_RET | unix.BPF_K:
+			return ins.K
+		default:
+			t.Fatalf("unexpected instruction %#x", ins.Code)
+		}
+	}
+	t.Fatal("filter fell through without returning")
+	return 0
+}
+
+func TestBuildSeccompFilter(t *testing.T) {
+	filter := buildSeccompFilter(unix.AUDIT_ARCH_X86_64, seccompDeniedSyscalls)
+	deny := unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+
+	for _, nr := range seccompDeniedSyscalls {
+		require.Equal(t, deny, runSeccompFilter(t, filter, unix.AUDIT_ARCH_X86_64, nr), "syscall %d should be denied", nr)
+	}
+	require.Equal(t, uint32(unix.SECCOMP_RET_ALLOW), runSeccompFilter(t, filter, unix.AUDIT_ARCH_X86_64, unix.SYS_WRITE))
+	require.Equal(t, deny, runSeccompFilter(t, filter, unix.AUDIT_ARCH_X86_64, seccompX32SyscallBit|unix.SYS_WRITE), "x32 syscalls should be denied")
+	require.Equal(t, uint32(unix.SECCOMP_RET_KILL_PROCESS), runSeccompFilter(t, filter, unix.AUDIT_ARCH_AARCH64, unix.SYS_WRITE), "foreign architecture should be killed")
+}
+
+func TestHardenProcessSeccomp(t *testing.T) {
+	if os.Getenv("SHAMIR_TEST_SECCOMP") == "1" {
+		// Runs in the child started below, so the filter does not leak into
+		// the rest of the test binary.
+		if err := hardenProcess(true); err != nil {
+			t.Skipf("seccomp unavailable: %v", err)
+		}
+		err := exec.Command("/bin/true").Run()
+		require.ErrorIs(t, err, unix.EPERM, "exec should be denied")
+		require.Equal(t, 0, mustPrctl(t, unix.PR_GET_DUMPABLE), "process should not be dumpable")
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHardenProcessSeccomp$", "-test.v")
+	cmd.Env = append(os.Environ(), "SHAMIR_TEST_SECCOMP=1")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "hardened child failed:\n%s", out)
+}
+
+func mustPrctl(t *testing.T, option int) int {
+	value, err := unix.PrctlRetInt(option, 0, 0, 0, 0)
+	require.NoError(t, err)
+	return value
+}
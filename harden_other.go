@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func hardenProcess(seccomp bool) error {
+	return fmt.Errorf("hardened mode is not supported on %s", runtime.GOOS)
+}
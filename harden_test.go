@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPrivateOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, checkPrivateOutput(f), "private file should be accepted")
+
+	require.NoError(t, os.Chmod(path, 0o644))
+	require.Error(t, checkPrivateOutput(f), "world-readable file should be refused")
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer devNull.Close()
+	require.NoError(t, checkPrivateOutput(devNull), "devices and pipes should be accepted")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// inspect reports what can be learned from shares without combining them,
+// which is safe to run anywhere: their modes, lengths and x coordinates, and
+// whether they can belong to the same split.
+
+type inspectShare struct {
+	jsonShare
+	X      int `json:"x"`
+	Length int `json:"length"`
+}
+
+type inspectResult struct {
+	jsonHeader
+	Mode          string         `json:"mode"`
+	PayloadLength int            `json:"payload_length"`
+	Padded        bool           `json:"padded"`
+	MnemonicWords int            `json:"mnemonic_words,omitempty"`
+	Shares        []inspectShare `json:"shares"`
+	Problems      []string       `json:"problems"`
+}
+
+// inspectShares describes the encoded shares and lists any reason they
+// cannot be combined. Malformed shares are an error.
+func inspectShares(encodedShares string) (inspectResult, error) {
+	result := inspectResult{Problems: []string{}}
+	modes := make(map[string]bool)
+	lengths := make(map[int]bool)
+	xSeen := make(map[int]int)
+	indexSeen := make(map[int]bool)
+
+	for _, encodedShare := range splitShareList(encodedShares) {
+		share, data, err := describeShare(encodedShare)
+		if err != nil {
+			return inspectResult{}, err
+		}
+		x := int(data[len(data)-1])
+		clear(data)
+
+		if xSeen[x] > 0 {
+			result.Problems = append(result.Problems, fmt.Sprintf("share %d has the same x coordinate as share %d", share.Index, xSeen[x]))
+		} else {
+			xSeen[x] = share.Index
+		}
+		if indexSeen[share.Index] {
+			result.Problems = append(result.Problems, fmt.Sprintf("index %d appears more than once", share.Index))
+		}
+		indexSeen[share.Index] = true
+		modes[share.Mode] = true
+		lengths[len(data)] = true
+
+		result.Mode = share.Mode
+		result.PayloadLength = len(data) - sss.ShareOverhead
+		result.Shares = append(result.Shares, inspectShare{jsonShare: share, X: x, Length: len(data)})
+	}
+
+	if len(modes) > 1 {
+		result.Problems = append(result.Problems, "shares use different modes")
+	}
+	if len(lengths) > 1 {
+		result.Problems = append(result.Problems, "shares have different lengths")
+	}
+	if len(result.Shares) < 2 {
+		result.Problems = append(result.Problems, "at least two shares are needed to restore a secret")
+	}
+	_, result.Padded = splitShareMode(result.Mode)
+	if result.Mode == shareModeBIP39 && len(modes) == 1 {
+		entropy := result.PayloadLength - bip39ChecksumSize
+		result.MnemonicWords = (entropy*8 + entropy/4) / 11
+	}
+	return result, nil
+}
+
+func formatInspectResult(result inspectResult) string {
+	var sb strings.Builder
+	for _, share := range result.Shares {
+		fmt.Fprintf(&sb, "%s  mode %s  x 0x%02x  %d bytes  fingerprint %s\n", share.Label, share.Mode, share.X, share.Length, share.Fingerprint)
+	}
+	fmt.Fprintf(&sb, "Shares: %d, mode %s, payload %d bytes", len(result.Shares), result.Mode, result.PayloadLength)
+	if result.MnemonicWords > 0 {
+		fmt.Fprintf(&sb, " (%d-word mnemonic)", result.MnemonicWords)
+	}
+	if result.Padded {
+		sb.WriteString(" (padded, secret length hidden)")
+	}
+	sb.WriteString("\n")
+	if len(result.Problems) == 0 {
+		sb.WriteString("No problems found")
+	}
+	for _, problem := range result.Problems {
+		fmt.Fprintf(&sb, "Problem: %s\n", problem)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func inspectCommand(flags *flag.FlagSet) func(args []string) int {
+	sharesArg := flags.String("shares", "", "comma-separated encoded shares")
+	output := flags.String("output", outputText, "output format: text or json")
+
+	return func(args []string) int {
+		out, err := newReporter("inspect", *output)
+		if err != nil {
+			return fail("Invalid arguments", err)
+		}
+		if err := bindPositional(flags, 1, "shares"); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+
+		result, err := inspectShares(*sharesArg)
+		if err != nil {
+			return out.fail("Error inspecting shares", err)
+		}
+
+		code := exitOK
+		result.jsonHeader = out.header()
+		if len(result.Problems) > 0 {
+			code = exitCode(sss.ErrInconsistentShares)
+			result.OK = false
+			result.Error = &jsonError{Code: code, Kind: errorKind(sss.ErrInconsistentShares), Message: strings.Join(result.Problems, "; ")}
+		}
+		if out.json() {
+			out.emit(result)
+		} else {
+			fmt.Println(formatInspectResult(result))
+		}
+		return code
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectShares(t *testing.T) {
+	mnemonic := []byte("pen aunt text rotate donate sock shield pottery cloud toy tank sibling parrot oblige agent egg october angle short wolf survey frequent autumn desert")
+	encoded, _, err := splitPrepared(deterministicReader("inspect"), mnemonic, secretTypeBIP39, true, "", 3, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encoded, ",")
+
+	result, err := inspectShares(encoded)
+	require.NoError(t, err)
+	require.Empty(t, result.Problems)
+	require.Equal(t, shareModeBIP39, result.Mode)
+	require.Equal(t, 34, result.PayloadLength)
+	require.Equal(t, 24, result.MnemonicWords)
+	require.Len(t, result.Shares, 3)
+
+	raw, _, err := splitPrepared(deterministicReader("inspect raw"), []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+	rawShares := strings.Split(raw, ",")
+
+	type testCase struct {
+		name     string
+		shares   []string
+		problems int
+	}
+
+	testCases := []testCase{
+		{name: "single share", shares: shares[:1], problems: 1},
+		{name: "duplicate share", shares: []string{shares[0], shares[1], shares[0]}, problems: 2},
+		{name: "mixed modes", shares: []string{shares[0], rawShares[1]}, problems: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := inspectShares(strings.Join(tc.shares, ","))
+			require.NoError(t, err)
+			require.Len(t, result.Problems, tc.problems)
+		})
+	}
+
+	for _, malformed := range []string{"1-zz", "1-", "1-ab,2-cd", "1-bip39-ab"} {
+		_, err = inspectShares(malformed)
+		require.Error(t, err, malformed)
+		require.Equal(t, exitMalformedShare, exitCode(err), malformed)
+	}
+	require.Equal(t, exitMalformedShare, runCLI([]string{"inspect", "1-"}))
+
+	require.Equal(t, exitInconsistentShares, runCLI([]string{"inspect", shares[0]}))
+}
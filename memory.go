@@ -0,0 +1,38 @@
+package main
+
+// secureBuffer holds secret material. On Linux it lives in its own anonymous
+// mapping that is locked into RAM and excluded from core dumps; elsewhere it
+// is ordinary heap memory. Destroy wipes the contents, so callers must not
+// keep references to Bytes afterwards.
+type secureBuffer struct {
+	data   []byte
+	mapped bool
+}
+
+// newSecureBufferFrom copies data into a new secure buffer.
+func newSecureBufferFrom(data []byte) (*secureBuffer, error) {
+	buf, err := newSecureBuffer(len(data))
+	if err != nil {
+		return nil, err
+	}
+	copy(buf.data, data)
+	return buf, nil
+}
+
+// Bytes returns the buffer contents. It is safe to call on a nil buffer.
+func (b *secureBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	return b.data
+}
+
+// Destroy wipes and releases the buffer. It is safe to call more than once.
+func (b *secureBuffer) Destroy() {
+	if b == nil || b.data == nil {
+		return
+	}
+	clear(b.data)
+	releaseSecureMemory(b)
+	b.data = nil
+}
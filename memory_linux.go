@@ -0,0 +1,44 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+var mlockWarning sync.Once
+
+func newSecureBuffer(size int) (*secureBuffer, error) {
+	if size == 0 {
+		return &secureBuffer{data: []byte{}}, nil
+	}
+
+	data, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate secure memory: %w", err)
+	}
+	if err := unix.Madvise(data, unix.MADV_DONTDUMP); err != nil {
+		unix.Munmap(data)
+		return nil, fmt.Errorf("failed to exclude secure memory from core dumps: %w", err)
+	}
+	// Locking can fail under a low RLIMIT_MEMLOCK, for example in containers.
+	// The secret is still kept out of core dumps, so warn and carry on.
+	if err := unix.Mlock(data); err != nil {
+		mlockWarning.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: could not lock secret memory, it may be swapped to disk: %v\n", err)
+		})
+	}
+	return &secureBuffer{data: data, mapped: true}, nil
+}
+
+func releaseSecureMemory(b *secureBuffer) {
+	if !b.mapped {
+		return
+	}
+	unix.Munlock(b.data)
+	unix.Munmap(b.data)
+}
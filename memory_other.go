@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+func newSecureBuffer(size int) (*secureBuffer, error) {
+	return &secureBuffer{data: make([]byte, size)}, nil
+}
+
+func releaseSecureMemory(b *secureBuffer) {}
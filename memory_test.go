@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureBuffer(t *testing.T) {
+	secret := []byte("my_secret")
+	buf, err := newSecureBufferFrom(secret)
+	require.NoError(t, err, "allocating secure memory should not fail")
+	require.Equal(t, secret, buf.Bytes(), "buffer should hold a copy of the data")
+
+	secret[0] = 'M'
+	require.Equal(t, "my_secret", string(buf.Bytes()), "buffer should not alias the source")
+
+	buf.Destroy()
+	require.Nil(t, buf.Bytes(), "destroyed buffer should be empty")
+	buf.Destroy()
+
+	empty, err := newSecureBuffer(0)
+	require.NoError(t, err)
+	require.Empty(t, empty.Bytes())
+	empty.Destroy()
+
+	var missing *secureBuffer
+	require.Nil(t, missing.Bytes())
+	missing.Destroy()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Machine-readable output (--output json) prints exactly one JSON object to
+// stdout, for failures too. Every object carries schema_version; fields are
+// only ever added within a version, so consumers should ignore unknown ones.
+
+const outputSchemaVersion = 1
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type jsonError struct {
+	Code    int    `json:"code"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// jsonHeader starts every JSON object.
+type jsonHeader struct {
+	SchemaVersion int        `json:"schema_version"`
+	Command       string     `json:"command"`
+	OK            bool       `json:"ok"`
+	Error         *jsonError `json:"error,omitempty"`
+}
+
+// jsonShare describes one share. Fingerprint identifies the share itself,
+// for example to check a transcription; it says nothing about the secret.
+type jsonShare struct {
+	Index       int    `json:"index"`
+	Label       string `json:"label"`
+	Encoding    string `json:"encoding"`
+	Mode        string `json:"mode"`
+	Payload     string `json:"payload"`
+	Fingerprint string `json:"fingerprint"`
+	QRFile      string `json:"qr_file,omitempty"`
+	SheetFile   string `json:"sheet_file,omitempty"`
+}
+
+type splitResult struct {
+	jsonHeader
+	SecretType        string      `json:"secret_type"`
+	Mode              string      `json:"mode"`
+	Threshold         int         `json:"threshold"`
+	TotalShares       int         `json:"total_shares"`
+	SetID             string      `json:"set_id"`
+	RandomSource      string      `json:"random_source"`
+	Generated         string      `json:"generated,omitempty"`
+	DiceRolls         int         `json:"dice_rolls,omitempty"`
+	SecretFingerprint string      `json:"secret_fingerprint,omitempty"`
+	Shares            []jsonShare `json:"shares"`
+}
+
+// restoreResult holds the secret unless only a fingerprint or wallet check
+// was asked for.
+type restoreResult struct {
+	jsonHeader
+	SecretType       string         `json:"secret_type"`
+	Secret           string         `json:"secret,omitempty"`
+	SecretEncoding   string         `json:"secret_encoding,omitempty"`
+	FingerprintMatch *bool          `json:"fingerprint_match,omitempty"`
+	Wallet           *walletSummary `json:"wallet,omitempty"`
+}
+
+// shareFingerprint returns the first 8 bytes of the SHA-256 of a share.
+func shareFingerprint(share []byte) string {
+	sum := sha256.Sum256(share)
+	return hex.EncodeToString(sum[:8])
+}
+
+// shareModeName names a share mode for output; raw shares have no mode in
+// their encoding.
+func shareModeName(mode string) string {
+	if mode == shareModeRaw {
+		return "raw"
+	}
+	return mode
+}
+
+// describeShare parses an encoded share for output without combining it.
+func describeShare(encodedShare string) (jsonShare, []byte, error) {
+	if isCodex32Shares(encodedShare) {
+		share, err := decodeCodex32(encodedShare)
+		if err != nil {
+			return jsonShare{}, nil, err
+		}
+		return jsonShare{
+			Index:       codex32ShareNumber(share.index),
+			Label:       fmt.Sprintf("Share %c", share.index),
+			Encoding:    shareFormatCodex32,
+			Mode:        shareModeName(shareModeRaw),
+			Payload:     encodedShare,
+			Fingerprint: shareFingerprint([]byte(strings.ToLower(encodedShare))),
+		}, share.data, nil
+	}
+	encoding, share, err := decodeShareRecord(encodedShare)
+	if err != nil {
+		return jsonShare{}, nil, err
+	}
+	return jsonShare{
+		Index:       share.Index,
+		Label:       fmt.Sprintf("Share %d", share.Index),
+		Encoding:    encoding.name(),
+		Mode:        shareModeName(share.Mode),
+		Payload:     encodedShare,
+		Fingerprint: shareFingerprint(share.Data),
+	}, share.Data, nil
+}
+
+// errorKind names the class of err for JSON output.
+func errorKind(err error) string {
+	switch exitCode(err) {
+	case exitUsage:
+		return "usage"
+	case exitInvalidParameters:
+		return "invalid_parameters"
+	case exitInvalidSecret:
+		return "invalid_secret"
+	case exitMalformedShare:
+		if errors.Is(err, sss.ErrDuplicateShare) {
+			return "duplicate_share"
+		}
+		return "malformed_share"
+	case exitInconsistentShares:
+		return "inconsistent_shares"
+	case exitInsufficientShares:
+		return "insufficient_shares"
+	case exitVerificationFailed:
+		return "verification_failed"
+	default:
+		return "error"
+	}
+}
+
+// reporter sends a command's result and failures to stdout as JSON, or to
+// stdout and stderr as text.
+type reporter struct {
+	command string
+	format  string
+}
+
+func newReporter(command, format string) (*reporter, error) {
+	if format != outputText && format != outputJSON {
+		return nil, sss.NewError(errUsage, fmt.Sprintf("unknown output format %q, use %q or %q", format, outputText, outputJSON), nil)
+	}
+	return &reporter{command: command, format: format}, nil
+}
+
+func (r *reporter) json() bool {
+	return r.format == outputJSON
+}
+
+func (r *reporter) header() jsonHeader {
+	return jsonHeader{SchemaVersion: outputSchemaVersion, Command: r.command, OK: true}
+}
+
+func (r *reporter) emit(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func (r *reporter) fail(msg string, err error) int {
+	if !r.json() {
+		return fail(msg, err)
+	}
+	return r.emitError(err, fmt.Sprintf("%s: %v", msg, err))
+}
+
+func (r *reporter) failWith(kind error, msg string) int {
+	if !r.json() {
+		return failWith(kind, msg)
+	}
+	return r.emitError(kind, msg)
+}
+
+func (r *reporter) emitError(err error, message string) int {
+	header := r.header()
+	header.OK = false
+	header.Error = &jsonError{Code: exitCode(err), Kind: errorKind(err), Message: message}
+	r.emit(header)
+	return header.Error.Code
+}
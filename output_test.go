@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tofel/shamir/sss"
+)
+
+func TestSplitJSON(t *testing.T) {
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--output", "json", "--fingerprint", "my_secret", "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+
+	var result splitResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Equal(t, outputSchemaVersion, result.SchemaVersion)
+	require.Equal(t, "split", result.Command)
+	require.True(t, result.OK)
+	require.Equal(t, 2, result.Threshold)
+	require.Equal(t, 3, result.TotalShares)
+	require.Equal(t, "raw", result.Mode)
+	require.True(t, strings.HasPrefix(result.SecretFingerprint, fingerprintPrefix+":"))
+	require.Len(t, result.Shares, 3)
+
+	payloads := make([]string, 0, len(result.Shares))
+	for i, share := range result.Shares {
+		require.Equal(t, i+1, share.Index)
+		require.Equal(t, "hex", share.Encoding)
+		require.Len(t, share.Fingerprint, 16)
+		payloads = append(payloads, share.Payload)
+	}
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--output", "json", "--fingerprint", result.SecretFingerprint, strings.Join(payloads[1:], ",")})
+	})
+	require.Equal(t, exitOK, code)
+
+	var restored restoreResult
+	require.NoError(t, json.Unmarshal([]byte(out), &restored))
+	require.True(t, restored.OK)
+	require.Equal(t, "my_secret", restored.Secret)
+	require.Equal(t, "utf-8", restored.SecretEncoding)
+	require.NotNil(t, restored.FingerprintMatch)
+	require.True(t, *restored.FingerprintMatch)
+}
+
+func TestRestoreJSONError(t *testing.T) {
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--output", "json", "1-zz,2-aa"})
+	})
+	require.Equal(t, exitMalformedShare, code)
+
+	var result restoreResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.False(t, result.OK)
+	require.Empty(t, result.Secret)
+	require.NotNil(t, result.Error)
+	require.Equal(t, exitMalformedShare, result.Error.Code)
+	require.Equal(t, "malformed_share", result.Error.Kind)
+
+	require.Equal(t, exitUsage, runCLI([]string{"restore", "--output", "yaml", "1-aa,2-bb"}))
+}
+
+func TestErrorKind(t *testing.T) {
+	require.Equal(t, "usage", errorKind(errUsage))
+	require.Equal(t, "duplicate_share", errorKind(sss.NewError(sss.ErrDuplicateShare, "duplicate", nil)))
+	require.Equal(t, "insufficient_shares", errorKind(sss.NewError(sss.ErrInsufficientShares, "too few", nil)))
+	require.Equal(t, "error", errorKind(errors.New("other")))
+}
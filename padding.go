@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// Shares are one byte longer than the data they carry, so every holder learns
+// the length of the secret, for example whether it is a 12- or a 24-word
+// mnemonic. Padded shares instead carry a two-byte big-endian length, the
+// payload and zero bytes up to a fixed size. The padding is marked in the
+// share mode, so restore can strip it again.
+
+const (
+	padPrefixSize = 2
+	padMaxPayload = 1<<16 - 1
+	padMinBucket  = 32
+	padBucket     = "bucket"
+
+	// shareModePadSuffix marks padded shares: "pad" for raw payloads and
+	// "bip39.pad" for compact ones.
+	shareModePadSuffix = ".pad"
+	shareModePadded    = "pad"
+
+	// bip39MaxMnemonicSize is the longest English mnemonic: 24 words of at
+	// most 8 letters and the spaces between them.
+	bip39MaxMnemonicSize = 24*8 + 23
+)
+
+// withPadding returns the share mode for a padded payload of the given mode.
+func withPadding(mode string) string {
+	if mode == shareModeRaw {
+		return shareModePadded
+	}
+	return mode + shareModePadSuffix
+}
+
+// splitShareMode separates a share mode into the payload mode and whether the
+// payload is padded.
+func splitShareMode(mode string) (base string, padded bool) {
+	if mode == shareModePadded {
+		return shareModeRaw, true
+	}
+	if base, ok := strings.CutSuffix(mode, shareModePadSuffix); ok {
+		return base, true
+	}
+	return mode, false
+}
+
+// padSize returns the padded length, prefix included, for a payload of
+// payloadLen bytes. spec is "bucket" or a length. Buckets are powers of two of
+// at least 32 bytes, except that every mnemonic is padded like the longest
+// 24-word one.
+func padSize(spec string, payloadLen int, secretType string, compact bool) (int, error) {
+	needed := payloadLen + padPrefixSize
+	if payloadLen > padMaxPayload {
+		return 0, sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("padded secrets cannot exceed %d bytes", padMaxPayload), nil)
+	}
+
+	if spec != padBucket {
+		size, err := strconv.Atoi(spec)
+		if err != nil {
+			return 0, sss.NewError(errUsage, fmt.Sprintf("invalid --pad value %q, expected bucket or a length", spec), nil)
+		}
+		if size < needed {
+			return 0, sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("--pad %d is too short, this secret needs at least %d bytes", size, needed), nil)
+		}
+		return size, nil
+	}
+
+	switch {
+	case compact:
+		return padPrefixSize + 32 + bip39ChecksumSize, nil
+	case secretType == secretTypeBIP39:
+		return padPrefixSize + bip39MaxMnemonicSize, nil
+	}
+	size := padMinBucket
+	for size < needed {
+		size *= 2
+	}
+	return size, nil
+}
+
+// padPayload returns payload with its length prefix and zero padding up to
+// size bytes in a new secure buffer.
+func padPayload(payload []byte, size int) (*secureBuffer, error) {
+	if len(payload) > padMaxPayload || len(payload)+padPrefixSize > size {
+		return nil, sss.NewError(sss.ErrInvalidParameters, "payload does not fit the padded size", nil)
+	}
+	padded, err := newSecureBuffer(size)
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(padded.Bytes(), uint16(len(payload)))
+	copy(padded.Bytes()[padPrefixSize:], payload)
+	return padded, nil
+}
+
+// unpadPayload returns the payload inside padded. Restoring from wrong or too
+// few shares almost always yields an impossible length or non-zero padding,
+// which is reported like any other failed check.
+func unpadPayload(padded []byte) ([]byte, error) {
+	if len(padded) < padPrefixSize {
+		return nil, sss.NewError(sss.ErrMalformedShare, "padded shares are too short", nil)
+	}
+	length := int(binary.BigEndian.Uint16(padded))
+	if length > len(padded)-padPrefixSize {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "restored padding is invalid, the shares may be wrong or insufficient", nil)
+	}
+	var rest byte
+	for _, b := range padded[padPrefixSize+length:] {
+		rest |= b
+	}
+	if rest != 0 {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "restored padding is invalid, the shares may be wrong or insufficient", nil)
+	}
+	return padded[padPrefixSize : padPrefixSize+length], nil
+}
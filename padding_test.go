@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tofel/shamir/sss"
+)
+
+func TestPadSize(t *testing.T) {
+	type testCase struct {
+		name       string
+		spec       string
+		payloadLen int
+		secretType string
+		compact    bool
+		expected   int
+		wantErr    bool
+	}
+
+	testCases := []testCase{
+		{name: "smallest bucket", spec: "bucket", payloadLen: 1, secretType: secretTypeText, expected: 32},
+		{name: "bucket edge", spec: "bucket", payloadLen: 30, secretType: secretTypeText, expected: 32},
+		{name: "next bucket", spec: "bucket", payloadLen: 31, secretType: secretTypeText, expected: 64},
+		{name: "mnemonic", spec: "bucket", payloadLen: 47, secretType: secretTypeBIP39, expected: 217},
+		{name: "compact mnemonic", spec: "bucket", payloadLen: 18, secretType: secretTypeBIP39, compact: true, expected: 36},
+		{name: "chosen length", spec: "100", payloadLen: 10, secretType: secretTypeText, expected: 100},
+		{name: "chosen length too short", spec: "11", payloadLen: 10, secretType: secretTypeText, wantErr: true},
+		{name: "invalid spec", spec: "large", payloadLen: 10, secretType: secretTypeText, wantErr: true},
+		{name: "too long", spec: "bucket", payloadLen: 1 << 16, secretType: secretTypeText, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := padSize(tc.spec, tc.payloadLen, tc.secretType, tc.compact)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, size)
+		})
+	}
+}
+
+func TestPadPayload(t *testing.T) {
+	padded, err := padPayload([]byte("my_secret"), 16)
+	require.NoError(t, err)
+	defer padded.Destroy()
+	require.Equal(t, []byte("\x00\x09my_secret\x00\x00\x00\x00\x00"), padded.Bytes())
+
+	payload, err := unpadPayload(padded.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, []byte("my_secret"), payload)
+
+	_, err = padPayload([]byte("my_secret"), 10)
+	require.Error(t, err, "payload longer than the padded size should fail")
+
+	_, err = unpadPayload([]byte("\x00\x09my_secret\x00\x01"))
+	require.ErrorIs(t, err, sss.ErrInsufficientShares, "non-zero padding should fail")
+	_, err = unpadPayload([]byte("\x00\x20my_secret"))
+	require.ErrorIs(t, err, sss.ErrInsufficientShares, "impossible length should fail")
+	_, err = unpadPayload([]byte("\x00"))
+	require.ErrorIs(t, err, sss.ErrMalformedShare)
+}
+
+func TestSplitPadded(t *testing.T) {
+	mnemonic := []byte("pen aunt text rotate donate sock shield pottery cloud toy tank sibling parrot oblige agent egg october angle short wolf survey frequent autumn desert")
+
+	type testCase struct {
+		name       string
+		secret     []byte
+		secretType string
+		compact    bool
+		pad        string
+		mode       string
+		shareLen   int
+	}
+
+	testCases := []testCase{
+		{name: "text", secret: []byte("my_secret"), secretType: secretTypeText, pad: "bucket", mode: "pad", shareLen: 33},
+		{name: "chosen length", secret: []byte("my_secret"), secretType: secretTypeText, pad: "50", mode: "pad", shareLen: 51},
+		{name: "mnemonic", secret: mnemonic, secretType: secretTypeBIP39, pad: "bucket", mode: "pad", shareLen: 218},
+		{name: "compact mnemonic", secret: mnemonic, secretType: secretTypeBIP39, compact: true, pad: "bucket", mode: "bip39.pad", shareLen: 37},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, mode, err := splitPrepared(deterministicReader(tc.name), tc.secret, tc.secretType, tc.compact, tc.pad, 3, 2)
+			require.NoError(t, err)
+			require.Equal(t, tc.mode, mode)
+
+			shares := strings.Split(encoded, ",")
+			for _, share := range shares {
+				shareMode, data, err := decodeShare(share)
+				require.NoError(t, err)
+				require.Equal(t, tc.mode, shareMode)
+				require.Len(t, data, tc.shareLen, "share length should not depend on the secret")
+			}
+
+			restored, err := restoreChecked(strings.Join(shares[1:], ","), tc.secretType)
+			require.NoError(t, err)
+			defer restored.Destroy()
+			require.Equal(t, tc.secret, restored.Bytes())
+		})
+	}
+
+	twelveWords := []byte("legal winner thank year wave sausage worth useful legal winner thank yellow")
+	short, _, err := splitPrepared(deterministicReader("short"), twelveWords, secretTypeBIP39, true, "bucket", 3, 2)
+	require.NoError(t, err)
+	long, _, err := splitPrepared(deterministicReader("long"), mnemonic, secretTypeBIP39, true, "bucket", 3, 2)
+	require.NoError(t, err)
+	require.Equal(t, len(short), len(long), "12- and 24-word mnemonics should give shares of the same length")
+
+	encoded, _, err := splitPrepared(deterministicReader("insufficient"), []byte("my_secret"), secretTypeText, false, "bucket", 3, 3)
+	require.NoError(t, err)
+	_, err = restoreSecret(strings.Join(strings.Split(encoded, ",")[:2], ","))
+	require.ErrorIs(t, err, sss.ErrInsufficientShares, "too few padded shares should be detected")
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/tofel/shamir/sss"
+)
+
+// split --print writes one printable sheet per shareholder. A sheet holds only
+// that holder's share: its text in short groups with a checksum per line, its
+// QR code, the details needed to find the other holders and instructions for
+// restoring. Nothing derived from the secret itself, such as its fingerprint,
+// is printed.
+
+const (
+	printHTML = "html"
+	printSVG  = "svg"
+
+	sheetGroupSize     = 4
+	sheetGroupsPerLine = 6
+
+	// Share lines of an SVG sheet go between these heights in mm, above the
+	// instructions at the bottom of the page. Long shares get their lines
+	// closer together, down to the minimum pitch.
+	sheetSVGFirstLineY   = 98
+	sheetSVGLastLineY    = 236
+	sheetSVGLinePitch    = 8
+	sheetSVGMinLinePitch = 6
+)
+
+// sheetLine is one printed line of a share.
+type sheetLine struct {
+	Number   int
+	Groups   []string
+	Checksum string
+}
+
+type sheet struct {
+	Index     int
+	Total     int
+	Threshold int
+	Holder    string
+	SetID     string
+	Mode      string
+	Prefix    string
+	Lines     []sheetLine
+	QR        template.HTML
+	QRPath    string
+	QRSize    int
+	// LinePitch is the distance between share lines on an SVG sheet.
+	LinePitch float64
+}
+
+// shareSetID identifies the shares of one split. It is derived from all of
+// them, so it tells nothing about the secret and differs for every split.
+func shareSetID(encodedShares string) string {
+	sum := sha256.Sum256([]byte(encodedShares))
+	id := strings.ToUpper(hex.EncodeToString(sum[:4]))
+	return id[:4] + "-" + id[4:]
+}
+
+// lineChecksum returns four hex digits that change when any character of a
+// printed line, or its position, is copied wrongly.
+func lineChecksum(number int, text string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", number, text)))
+	return hex.EncodeToString(sum[:2])
+}
+
+// sheetLines cuts the data of a share, after its "N-", "N-mode-" or "ms1"
+// prefix, into numbered lines of short groups. Base32 shares have no prefix
+// and are regrouped without their hyphens.
+func sheetLines(share string) (string, []sheetLine) {
+	var prefix string
+	switch {
+	case isCodex32Shares(share):
+		prefix, share = share[:len(codex32Prefix)], share[len(codex32Prefix):]
+	case isHexShare(share):
+		cut := strings.LastIndex(share, "-") + 1
+		prefix, share = share[:cut], share[cut:]
+	default:
+		share = strings.ReplaceAll(share, "-", "")
+	}
+	var lines []sheetLine
+	lineSize := sheetGroupSize * sheetGroupsPerLine
+	for start := 0; start < len(share); start += lineSize {
+		text := share[start:min(start+lineSize, len(share))]
+		line := sheetLine{Number: len(lines) + 1, Checksum: lineChecksum(len(lines)+1, text)}
+		for g := 0; g < len(text); g += sheetGroupSize {
+			line.Groups = append(line.Groups, text[g:min(g+sheetGroupSize, len(text))])
+		}
+		lines = append(lines, line)
+	}
+	return prefix, lines
+}
+
+// qrSVGPath returns an SVG path drawing the dark modules, one unit each.
+func qrSVGPath(matrix *gozxing.BitMatrix) string {
+	var sb strings.Builder
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			if matrix.Get(x, y) {
+				fmt.Fprintf(&sb, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// parseHolders splits the --holders list, or names holders by number.
+func parseHolders(list string, total int) ([]string, error) {
+	holders := make([]string, total)
+	if list == "" {
+		for i := range holders {
+			holders[i] = fmt.Sprintf("Shareholder %d", i+1)
+		}
+		return holders, nil
+	}
+	names := strings.Split(list, ",")
+	if len(names) != total {
+		return nil, fmt.Errorf("--holders gives %d names for %d shares", len(names), total)
+	}
+	for i, name := range names {
+		holders[i] = strings.TrimSpace(name)
+	}
+	return holders, nil
+}
+
+// svgLinePitch returns the distance between the share lines of an SVG sheet
+// with the given number of lines, or an error if they do not fit on the page.
+func svgLinePitch(lines int) (float64, error) {
+	if lines <= 1 {
+		return sheetSVGLinePitch, nil
+	}
+	pitch := min(sheetSVGLinePitch, float64(sheetSVGLastLineY-sheetSVGFirstLineY)/float64(lines-1))
+	if pitch < sheetSVGMinLinePitch {
+		maxLines := (sheetSVGLastLineY-sheetSVGFirstLineY)/sheetSVGMinLinePitch + 1
+		return 0, sss.NewError(errUsage, fmt.Sprintf("the share needs %d lines, an SVG sheet holds at most %d; use --print-format html", lines, maxLines), nil)
+	}
+	return pitch, nil
+}
+
+// writeShareSheets writes sheet-N.html or sheet-N.svg for every share to dir
+// and returns their paths. Files are readable only by the owner and existing
+// ones are not replaced.
+func writeShareSheets(dir, format string, encodedShares string, mode string, threshold int, holders []string) ([]string, error) {
+	tmpl := sheetHTMLTemplate
+	if format == printSVG {
+		tmpl = sheetSVGTemplate
+	}
+	shares := strings.Split(encodedShares, ",")
+	setID := shareSetID(encodedShares)
+
+	paths := make([]string, 0, len(shares))
+	for i, share := range shares {
+		matrix, err := shareQRMatrix(share)
+		if err != nil {
+			return paths, err
+		}
+		path := qrSVGPath(matrix)
+		prefix, lines := sheetLines(share)
+		pitch := float64(sheetSVGLinePitch)
+		if format == printSVG {
+			if pitch, err = svgLinePitch(len(lines)); err != nil {
+				return paths, err
+			}
+		}
+		s := sheet{
+			Index:     i + 1,
+			Total:     len(shares),
+			Threshold: threshold,
+			Holder:    holders[i],
+			SetID:     setID,
+			Mode:      shareModeName(mode),
+			Prefix:    prefix,
+			Lines:     lines,
+			QR:        template.HTML(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]d %[1]d" width="60mm" height="60mm" shape-rendering="crispEdges"><rect width="%[1]d" height="%[1]d" fill="#fff"/><path d="%[2]s" fill="#000"/></svg>`, matrix.GetWidth(), path)),
+			QRPath:    path,
+			QRSize:    matrix.GetWidth(),
+			LinePitch: pitch,
+		}
+
+		name := filepath.Join(dir, fmt.Sprintf("sheet-%d.%s", i+1, format))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return paths, err
+		}
+		if err := tmpl.Execute(f, s); err != nil {
+			f.Close()
+			return paths, err
+		}
+		if err := f.Close(); err != nil {
+			return paths, err
+		}
+		paths = append(paths, name)
+	}
+	return paths, nil
+}
+
+var sheetFuncs = template.FuncMap{
+	"join": strings.Join,
+	"lineY": func(i int, pitch float64) string {
+		return fmt.Sprintf("%.2f", sheetSVGFirstLineY+float64(i)*pitch)
+	},
+	"scale": func(size int) string {
+		return fmt.Sprintf("%.4f", 60/float64(size))
+	},
+}
+
+var sheetHTMLTemplate = template.Must(template.New("sheet.html").Funcs(sheetFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Share {{.Index}} of {{.Total}}, set {{.SetID}}</title>
+<style>
+@page { size: A4; margin: 15mm; }
+body { font-family: sans-serif; font-size: 11pt; color: #000; max-width: 180mm; margin: 0 auto; }
+h1 { font-size: 18pt; margin-bottom: 2mm; }
+table.details td { padding: 1mm 6mm 1mm 0; }
+.qr { float: right; margin-left: 6mm; }
+table.share { font-family: monospace; font-size: 14pt; border-collapse: collapse; margin: 6mm 0; clear: both; }
+table.share td { padding: 1.5mm 3mm; border-bottom: 1px solid #ccc; }
+table.share td.number, table.share td.checksum { color: #555; font-size: 10pt; }
+ol li { margin-bottom: 2mm; }
+</style>
+</head>
+<body>
+<div class="qr">{{.QR}}</div>
+<h1>Secret share {{.Index}} of {{.Total}}</h1>
+<table class="details">
+<tr><td>Holder</td><td><strong>{{.Holder}}</strong></td></tr>
+<tr><td>Set ID</td><td>{{.SetID}}</td></tr>
+<tr><td>Threshold</td><td>{{.Threshold}} of {{.Total}} shares restore the secret</td></tr>
+<tr><td>Share mode</td><td>{{.Mode}}</td></tr>
+</table>
+<table class="share">
+{{- if .Prefix}}
+<tr><td class="number">00</td><td>{{.Prefix}}</td><td class="checksum"></td></tr>
+{{- end}}
+{{- range .Lines}}
+<tr><td class="number">{{printf "%02d" .Number}}</td><td>{{join .Groups " "}}</td><td class="checksum">{{.Checksum}}</td></tr>
+{{- end}}
+</table>
+<h2>Restoring the secret</h2>
+<ol>
+<li>Bring together {{.Threshold}} of the {{.Total}} shares with set ID {{.SetID}}. Shares from other sets cannot be combined.</li>
+<li>On an offline machine, scan the QR code, or type the share: line 00, if there is one, followed by the groups of every other line in order, without spaces. The gray code after each line is a checksum of that line and its position; it is not part of the share.</li>
+<li>Run <code>shamir restore</code> with the shares separated by commas, <code>shamir restore --interactive</code> to type them line by line with their gray codes, or <code>shamir restore --from-qr</code> with photos of the codes.</li>
+</ol>
+<p>This sheet holds only your share. On its own it reveals nothing about the secret, but anyone who collects {{.Threshold}} shares can restore it. Keep it private.</p>
+</body>
+</html>
+`))
+
+var sheetSVGTemplate = template.Must(template.New("sheet.svg").Funcs(sheetFuncs).Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="210mm" height="297mm" viewBox="0 0 210 297" font-family="sans-serif">
+<rect width="210" height="297" fill="#fff"/>
+<text x="15" y="25" font-size="7">Secret share {{.Index}} of {{.Total}}</text>
+<text x="15" y="36" font-size="4">Holder: {{.Holder}}</text>
+<text x="15" y="42" font-size="4">Set ID: {{.SetID}}</text>
+<text x="15" y="48" font-size="4">Threshold: {{.Threshold}} of {{.Total}} shares restore the secret</text>
+<text x="15" y="54" font-size="4">Share mode: {{.Mode}}</text>
+<g transform="translate(135 15) scale({{scale .QRSize}})" shape-rendering="crispEdges"><path d="{{.QRPath}}" fill="#000"/></g>
+<g font-family="monospace" font-size="5">
+{{- if .Prefix}}
+<text x="15" y="90"><tspan fill="#555" font-size="3.5">00</tspan><tspan x="25">{{.Prefix}}</tspan></text>
+{{- end}}
+{{- range $i, $line := .Lines}}
+<text x="15" y="{{lineY $i $.LinePitch}}"><tspan fill="#555" font-size="3.5">{{printf "%02d" $line.Number}}</tspan><tspan x="25">{{join $line.Groups " "}}</tspan><tspan x="120" fill="#555" font-size="3.5">{{$line.Checksum}}</tspan></text>
+{{- end}}
+</g>
+<g font-size="3.5">
+<text x="15" y="244">To restore, bring together {{.Threshold}} of the {{.Total}} shares with set ID {{.SetID}}</text>
+<text x="15" y="249">on an offline machine. Scan the QR code, or type the share: line 00, if there is one,</text>
+<text x="15" y="254">then the groups of every other line in order. The gray code after each</text>
+<text x="15" y="259">line is a checksum of that line and its position; it is not part of the share.</text>
+<text x="15" y="264">Then run shamir restore with the shares separated by commas, shamir restore</text>
+<text x="15" y="269">--interactive to type them line by line with their gray codes, or shamir restore</text>
+<text x="15" y="274">--from-qr with photos of the codes.</text>
+<text x="15" y="284">This sheet holds only your share. Anyone who collects {{.Threshold}} shares can restore</text>
+<text x="15" y="289">the secret. Keep it private.</text>
+</g>
+</svg>
+`))
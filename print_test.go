@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSheetLines(t *testing.T) {
+	data := strings.Repeat("0123456789abcdef", 4)
+	prefix, lines := sheetLines("3-pad-" + data)
+	require.Equal(t, "3-pad-", prefix)
+	require.Len(t, lines, 3)
+	require.Equal(t, []string{"0123", "4567", "89ab", "cdef", "0123", "4567"}, lines[0].Groups)
+	require.Equal(t, []string{"0123", "4567", "89ab", "cdef"}, lines[2].Groups)
+
+	var joined string
+	for i, line := range lines {
+		require.Equal(t, i+1, line.Number)
+		text := strings.Join(line.Groups, "")
+		require.Equal(t, lineChecksum(line.Number, text), line.Checksum)
+		joined += text
+	}
+	require.Equal(t, data, joined)
+
+	require.NotEqual(t, lineChecksum(1, "0123"), lineChecksum(2, "0123"), "the checksum should cover the line number")
+	require.NotEqual(t, lineChecksum(1, "0123"), lineChecksum(1, "0124"))
+}
+
+func TestShareSetID(t *testing.T) {
+	id := shareSetID("1-abcd,2-ef01")
+	require.Regexp(t, `^[0-9A-F]{4}-[0-9A-F]{4}$`, id)
+	require.Equal(t, id, shareSetID("1-abcd,2-ef01"))
+	require.NotEqual(t, id, shareSetID("1-abcd,2-ef02"))
+}
+
+func TestParseHolders(t *testing.T) {
+	holders, err := parseHolders("", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Shareholder 1", "Shareholder 2"}, holders)
+
+	holders, err = parseHolders("Alice, Bob ,Carol", 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Alice", "Bob", "Carol"}, holders)
+
+	_, err = parseHolders("Alice,Bob", 3)
+	require.ErrorContains(t, err, "2 names for 3 shares")
+}
+
+func TestWriteShareSheets(t *testing.T) {
+	encodedShares, _, err := splitPrepared(deterministicReader("sheets"), []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encodedShares, ",")
+	holders := []string{"Alice", "Bob", "<Carol>"}
+
+	for _, format := range []string{printHTML, printSVG} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			paths, err := writeShareSheets(dir, format, encodedShares, shareModeRaw, 2, holders)
+			require.NoError(t, err)
+			require.Len(t, paths, 3)
+
+			for i, path := range paths {
+				require.Equal(t, filepath.Join(dir, fmt.Sprintf("sheet-%d.%s", i+1, format)), path)
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				require.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "sheets should be private")
+
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+				content := string(data)
+				require.Contains(t, content, shareSetID(encodedShares))
+				require.Contains(t, content, "2 of 3 shares")
+				require.NotContains(t, content, "<Carol>", "holder names should be escaped")
+
+				// The typed groups and the prefix must add up to this share,
+				// and to no other.
+				prefix, lines := sheetLines(shares[i])
+				require.Contains(t, content, prefix)
+				for _, line := range lines {
+					require.Contains(t, content, strings.Join(line.Groups, " "))
+					require.Contains(t, content, line.Checksum)
+				}
+				for j, other := range shares {
+					if j == i {
+						continue
+					}
+					_, otherLines := sheetLines(other)
+					require.NotContains(t, content, strings.Join(otherLines[0].Groups, " "))
+				}
+			}
+			data, err := os.ReadFile(paths[0])
+			require.NoError(t, err)
+			require.Contains(t, string(data), "Alice")
+
+			_, err = writeShareSheets(dir, format, encodedShares, shareModeRaw, 2, holders)
+			require.Error(t, err, "existing sheets should not be replaced")
+		})
+	}
+}
+
+func TestWriteLongShareSheets(t *testing.T) {
+	lineY := regexp.MustCompile(`<text x="15" y="([0-9]+\.[0-9]{2})">`)
+	holders := []string{"Alice", "Bob"}
+	tests := []struct {
+		name   string
+		size   int
+		svgErr bool
+	}{
+		{"short", 9, false},
+		{"fills the page", 250, false},
+		{"too long for svg", 400, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encodedShares, _, err := splitPrepared(deterministicReader("long"), []byte(strings.Repeat("a", tt.size)), secretTypeText, false, "", 2, 2)
+			require.NoError(t, err)
+
+			paths, err := writeShareSheets(t.TempDir(), printHTML, encodedShares, shareModeRaw, 2, holders)
+			require.NoError(t, err, "HTML sheets flow onto more pages")
+			require.Len(t, paths, 2)
+
+			dir := t.TempDir()
+			paths, err = writeShareSheets(dir, printSVG, encodedShares, shareModeRaw, 2, holders)
+			if tt.svgErr {
+				require.Error(t, err)
+				require.Equal(t, exitUsage, exitCode(err))
+				require.Contains(t, err.Error(), "--print-format html")
+				return
+			}
+			require.NoError(t, err)
+
+			// Every share line must stay above the instructions.
+			data, err := os.ReadFile(paths[0])
+			require.NoError(t, err)
+			matches := lineY.FindAllStringSubmatch(string(data), -1)
+			_, lines := sheetLines(strings.Split(encodedShares, ",")[0])
+			require.Len(t, matches, len(lines))
+			for _, m := range matches {
+				y, err := strconv.ParseFloat(m[1], 64)
+				require.NoError(t, err)
+				require.LessOrEqual(t, y, float64(sheetSVGLastLineY))
+			}
+		})
+	}
+}
+
+func TestSplitPrint(t *testing.T) {
+	dir := t.TempDir()
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--output", "json", "--print", dir, "--print-format", "svg", "--holders", "Alice,Bob,Carol", "my_secret", "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	require.Regexp(t, regexp.MustCompile(`"set_id": "[0-9A-F]{4}-[0-9A-F]{4}"`), out)
+	require.Contains(t, out, `"sheet_file": "`+filepath.Join(dir, "sheet-3.svg")+`"`)
+
+	data, err := os.ReadFile(filepath.Join(dir, "sheet-2.svg"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "Holder: Bob")
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--print", dir, "--print-format", "pdf", "my_secret", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--print", dir, "--holders", "Alice", "my_secret", "2", "3"}))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	multiqr "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/tofel/shamir/sss"
+)
+
+// QR codes carry shares between airgapped machines through a printer or a
+// camera. They hold the encoded share text exactly as printed by split, and
+// are rendered and decoded entirely in process.
+
+const (
+	qrTerminal = "terminal"
+	qrPNG      = "png"
+
+	// qrPNGScale is the size of one module in PNG files, in pixels.
+	qrPNGScale = 8
+)
+
+// shareQRMatrix encodes a share as a QR code with medium error correction
+// and the standard four-module quiet zone. Set bits are dark modules.
+func shareQRMatrix(share string) (*gozxing.BitMatrix, error) {
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_ERROR_CORRECTION: decoder.ErrorCorrectionLevel_M,
+	}
+	return qrcode.NewQRCodeWriter().Encode(share, gozxing.BarcodeFormat_QR_CODE, 0, 0, hints)
+}
+
+// renderQRTerminal draws a QR code with half-block characters, two module
+// rows per line. Light modules are drawn, so the code reads as dark on light
+// on a terminal with a dark background.
+func renderQRTerminal(matrix *gozxing.BitMatrix) string {
+	var sb strings.Builder
+	light := func(x, y int) bool {
+		return y >= matrix.GetHeight() || !matrix.Get(x, y)
+	}
+	for y := 0; y < matrix.GetHeight(); y += 2 {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			switch top, bottom := light(x, y), light(x, y+1); {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// qrImage renders a QR code as a grayscale image with scale pixels per module.
+func qrImage(matrix *gozxing.BitMatrix, scale int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, matrix.GetWidth()*scale, matrix.GetHeight()*scale))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			shade := color.Gray{Y: 0xff}
+			if matrix.Get(x/scale, y/scale) {
+				shade = color.Gray{}
+			}
+			img.SetGray(x, y, shade)
+		}
+	}
+	return img
+}
+
+// writeShareQRPNG writes the QR code of a share to dir/share-N.png, readable
+// only by the owner, and returns the path. Existing files are not replaced.
+func writeShareQRPNG(dir string, index int, share string) (string, error) {
+	matrix, err := shareQRMatrix(share)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("share-%d.png", index))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if err := png.Encode(f, qrImage(matrix, qrPNGScale)); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// readQRShares decodes every QR code in a PNG or JPEG image. A photo may show
+// several shares at once.
+func readQRShares(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("cannot read image %s", path), err)
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	results, err := multiqr.NewQRCodeMultiReader().DecodeMultiple(bitmap, hints)
+	if err != nil || len(results) == 0 {
+		// The multi reader misses some codes the plain reader finds, for
+		// example ones that fill the whole image.
+		result, singleErr := qrcode.NewQRCodeReader().Decode(bitmap, hints)
+		if singleErr != nil {
+			return nil, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("no QR code found in %s", path), errors.Join(err, singleErr))
+		}
+		results = []*gozxing.Result{result}
+	}
+
+	shares := make([]string, 0, len(results))
+	for _, result := range results {
+		shares = append(shares, strings.TrimSpace(result.GetText()))
+	}
+	return shares, nil
+}
+
+// pathList collects the values of a repeatable file flag such as --from-qr.
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
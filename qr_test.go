@@ -0,0 +1,113 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareQRRoundTrip(t *testing.T) {
+	encodedShares, _, err := splitPrepared(deterministicReader("qr"), []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encodedShares, ",")
+	dir := t.TempDir()
+
+	for i, share := range shares {
+		path, err := writeShareQRPNG(dir, i+1, share)
+		require.NoError(t, err)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "QR files should be private")
+
+		decoded, err := readQRShares(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{share}, decoded)
+	}
+
+	_, err = writeShareQRPNG(dir, 1, shares[0])
+	require.Error(t, err, "existing files should not be replaced")
+
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--from-qr", filepath.Join(dir, "share-3.png"), "--from-qr", filepath.Join(dir, "share-1.png")})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--from-qr", filepath.Join(dir, "share-2.png"), shares[0]})
+	})
+	require.Equal(t, exitOK, code, "QR codes and typed shares should mix")
+	require.Equal(t, "my_secret\n", out)
+}
+
+func TestReadQRSharesFromPhoto(t *testing.T) {
+	encodedShares, _, err := splitPrepared(deterministicReader("photo"), []byte("my_secret"), secretTypeText, false, "", 2, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encodedShares, ",")
+
+	// Two codes side by side on one page, saved as a JPEG like a camera would.
+	var codes []*image.Gray
+	for _, share := range shares {
+		matrix, err := shareQRMatrix(share)
+		require.NoError(t, err)
+		codes = append(codes, qrImage(matrix, 6))
+	}
+	width := codes[0].Rect.Dx()
+	page := image.NewGray(image.Rect(0, 0, 2*width+40, width+40))
+	draw.Draw(page, page.Rect, image.White, image.Point{}, draw.Src)
+	draw.Draw(page, codes[0].Rect.Add(image.Pt(10, 20)), codes[0], image.Point{}, draw.Src)
+	draw.Draw(page, codes[1].Rect.Add(image.Pt(width+30, 20)), codes[1], image.Point{}, draw.Src)
+
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, jpeg.Encode(f, page, &jpeg.Options{Quality: 75}))
+	require.NoError(t, f.Close())
+
+	decoded, err := readQRShares(path)
+	require.NoError(t, err)
+	sort.Strings(decoded)
+	require.Equal(t, shares, decoded)
+}
+
+func TestReadQRSharesErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	blank := filepath.Join(dir, "blank.png")
+	f, err := os.Create(blank)
+	require.NoError(t, err)
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Rect, image.White, image.Point{}, draw.Src)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+	_, err = readQRShares(blank)
+	require.ErrorContains(t, err, "no QR code found")
+
+	text := filepath.Join(dir, "share.txt")
+	require.NoError(t, os.WriteFile(text, []byte("1-abcd"), 0o600))
+	_, err = readQRShares(text)
+	require.ErrorContains(t, err, "cannot read image")
+
+	require.Equal(t, exitMalformedShare, runCLI([]string{"restore", "--from-qr", blank}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--qr", "svg", "my_secret", "2", "3"}))
+}
+
+func TestRenderQRTerminal(t *testing.T) {
+	matrix, err := shareQRMatrix("1-abcd")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(renderQRTerminal(matrix), "\n"), "\n")
+	require.Len(t, lines, (matrix.GetHeight()+1)/2)
+	for _, line := range lines {
+		require.Equal(t, matrix.GetWidth(), len([]rune(line)))
+	}
+	require.Equal(t, strings.Repeat("█", matrix.GetWidth()), lines[0], "the quiet zone should be light")
+}
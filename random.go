@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	mathrand "math/rand/v2"
+	"os"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// split reads all of its randomness, for the shares and the fingerprint salt,
+// from one source. By default that is the operating system; a ceremony can
+// supply audited entropy from a file or device instead, and known-answer tests
+// can use a deterministic stream derived from a seed.
+
+const (
+	randomSourceSystem                = "system"
+	randomSourceEntropyFile           = "entropy_file"
+	randomSourceInsecureDeterministic = "insecure_deterministic"
+)
+
+// insecureDeterministicWarning is printed whenever shares are dealt from
+// --insecure-deterministic-seed.
+const insecureDeterministicWarning = "WARNING: --insecure-deterministic-seed is for tests only. Anyone who knows the seed can recompute these shares and the secret."
+
+// deterministicSeedDomain separates the deterministic stream from any other
+// use of the same seed string.
+const deterministicSeedDomain = "shamir insecure deterministic seed v1\x00"
+
+// openRandomSource returns the reader selected by the split flags, its name
+// and a function releasing it.
+func openRandomSource(entropyFile string, deterministicSeed string) (io.Reader, string, func(), error) {
+	switch {
+	case entropyFile != "" && deterministicSeed != "":
+		return nil, "", nil, sss.NewError(errUsage, "--entropy-file cannot be combined with --insecure-deterministic-seed", nil)
+	case entropyFile != "":
+		f, err := os.Open(entropyFile)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return &entropyFileReader{r: f}, randomSourceEntropyFile, func() { f.Close() }, nil
+	case deterministicSeed != "":
+		return deterministicReader(deterministicSeed), randomSourceInsecureDeterministic, func() {}, nil
+	default:
+		return rand.Reader, randomSourceSystem, func() {}, nil
+	}
+}
+
+// deterministicReader returns a ChaCha8 stream keyed by the SHA-256 of seed.
+// Anyone who knows the seed can recompute every share, so it is only for
+// tests.
+func deterministicReader(seed string) io.Reader {
+	key := sha256.Sum256([]byte(deterministicSeedDomain + seed))
+	defer clear(key[:])
+	return mathrand.NewChaCha8(key)
+}
+
+// entropyFileReader reports a clear error when the file runs out, instead
+// of the io.ErrUnexpectedEOF a short read would otherwise surface as.
+type entropyFileReader struct {
+	r io.Reader
+}
+
+func (e *entropyFileReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		err = errors.New("entropy file exhausted")
+	}
+	return n, err
+}
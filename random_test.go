@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRandomSource(t *testing.T) {
+	entropy := make([]byte, 256)
+	for i := range entropy {
+		entropy[i] = byte(i)
+	}
+	entropyFile := filepath.Join(t.TempDir(), "entropy")
+	require.NoError(t, os.WriteFile(entropyFile, entropy, 0o600))
+
+	type testCase struct {
+		name        string
+		entropyFile string
+		seed        string
+		expected    string
+		wantErr     bool
+	}
+
+	testCases := []testCase{
+		{name: "system", expected: randomSourceSystem},
+		{name: "entropy file", entropyFile: entropyFile, expected: randomSourceEntropyFile},
+		{name: "deterministic", seed: "test", expected: randomSourceInsecureDeterministic},
+		{name: "both", entropyFile: entropyFile, seed: "test", wantErr: true},
+		{name: "missing file", entropyFile: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			random, name, closeRandom, err := openRandomSource(tc.entropyFile, tc.seed)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer closeRandom()
+			require.Equal(t, tc.expected, name)
+
+			encodedShares, _, err := splitPrepared(random, []byte("my_secret"), secretTypeText, false, "", 3, 2)
+			require.NoError(t, err)
+			restored, err := restoreSecret(encodedShares)
+			require.NoError(t, err)
+			defer restored.Destroy()
+			require.Equal(t, "my_secret", string(restored.Bytes()))
+		})
+	}
+}
+
+func TestEntropyFileExhausted(t *testing.T) {
+	entropyFile := filepath.Join(t.TempDir(), "entropy")
+	require.NoError(t, os.WriteFile(entropyFile, []byte(strings.Repeat("\x07", 70)), 0o600))
+
+	random, _, closeRandom, err := openRandomSource(entropyFile, "")
+	require.NoError(t, err)
+	defer closeRandom()
+
+	_, _, err = splitPrepared(random, []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.ErrorContains(t, err, "entropy file exhausted")
+	require.Equal(t, exitRandomness, exitCode(err))
+}
+
+func TestDeterministicSplit(t *testing.T) {
+	args := []string{"split", "--fingerprint", "--insecure-deterministic-seed", "test", "my_secret", "2", "3"}
+	first := captureStdout(t, func() { require.Equal(t, exitOK, runCLI(args)) })
+	second := captureStdout(t, func() { require.Equal(t, exitOK, runCLI(args)) })
+	require.Equal(t, first, second, "the same seed should give the same shares and fingerprint")
+
+	args[3] = "other"
+	third := captureStdout(t, func() { require.Equal(t, exitOK, runCLI(args)) })
+	require.NotEqual(t, first, third, "a different seed should give different shares")
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--entropy-file", "/dev/urandom", "--insecure-deterministic-seed", "test", "my_secret", "2", "3"}))
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// The self-test checks this build against the known-answer vectors in
+// testvectors/v1.json, which pin down the field arithmetic, the shares a split
+// produces for a fixed random stream, combining shares from other
+// implementations and the share encoding. It runs before every split, so a
+// miscompiled or tampered binary refuses to create shares.
+
+//go:embed testvectors/v1.json
+var testVectorsJSON []byte
+
+// testVectorsVersion is the vector file version this build understands.
+const testVectorsVersion = 1
+
+type testVectors struct {
+	Version int `json:"version"`
+	Field   struct {
+		Mul     [][3]uint8 `json:"mul"`
+		Inverse [][2]uint8 `json:"inverse"`
+	} `json:"field"`
+	Split    []splitVector    `json:"split"`
+	Combine  []combineVector  `json:"combine"`
+	Encoding []encodingVector `json:"encoding"`
+}
+
+type splitVector struct {
+	Name       string   `json:"name"`
+	Secret     string   `json:"secret"`
+	SecretType string   `json:"secret_type"`
+	Compact    bool     `json:"compact"`
+	Pad        string   `json:"pad,omitempty"`
+	Threshold  int      `json:"threshold"`
+	Total      int      `json:"total"`
+	Random     string   `json:"random"`
+	Shares     []string `json:"shares"`
+}
+
+type combineVector struct {
+	Name       string   `json:"name"`
+	Shares     []string `json:"shares"`
+	Secret     string   `json:"secret"`
+	SecretType string   `json:"secret_type"`
+}
+
+type encodingVector struct {
+	Share string `json:"share"`
+	Index int    `json:"index"`
+	Mode  string `json:"mode"`
+	Data  string `json:"data"`
+	Error string `json:"error"`
+}
+
+func loadTestVectors(data []byte) (testVectors, error) {
+	var vectors testVectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return testVectors{}, err
+	}
+	if vectors.Version != testVectorsVersion {
+		return testVectors{}, fmt.Errorf("unsupported test vector version %d", vectors.Version)
+	}
+	return vectors, nil
+}
+
+func checkFieldVectors(vectors testVectors) error {
+	for _, v := range vectors.Field.Mul {
+		if got := sss.Mul(v[0], v[1]); got != v[2] {
+			return fmt.Errorf("%d * %d = %d, expected %d", v[0], v[1], got, v[2])
+		}
+	}
+	for _, v := range vectors.Field.Inverse {
+		if got := sss.Inverse(v[0]); got != v[1] {
+			return fmt.Errorf("inverse of %d = %d, expected %d", v[0], got, v[1])
+		}
+	}
+	return nil
+}
+
+func checkSplitVectors(vectors testVectors) error {
+	for _, v := range vectors.Split {
+		random, err := hex.DecodeString(v.Random)
+		if err != nil {
+			return fmt.Errorf("%s: invalid random bytes: %w", v.Name, err)
+		}
+		encoded, _, err := splitPrepared(bytes.NewReader(random), []byte(v.Secret), v.SecretType, v.Compact, v.Pad, v.Total, v.Threshold)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+		if encoded != strings.Join(v.Shares, ",") {
+			return fmt.Errorf("%s: shares differ from the vector", v.Name)
+		}
+	}
+	return nil
+}
+
+func checkCombineVectors(vectors testVectors) error {
+	for _, v := range vectors.Combine {
+		secret, err := restoreChecked(strings.Join(v.Shares, ","), v.SecretType)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+		matches := string(secret.Bytes()) == v.Secret
+		secret.Destroy()
+		if !matches {
+			return fmt.Errorf("%s: restored secret differs from the vector", v.Name)
+		}
+	}
+	return nil
+}
+
+func checkEncodingVectors(vectors testVectors) error {
+	for _, v := range vectors.Encoding {
+		share, data, err := describeShare(v.Share)
+		if v.Error != "" {
+			if err == nil || errorKind(err) != v.Error {
+				return fmt.Errorf("%s: expected a %s error, got %v", v.Share, v.Error, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Share, err)
+		}
+		if share.Index != v.Index || share.Mode != v.Mode || hex.EncodeToString(data) != v.Data {
+			return fmt.Errorf("%s: decoded share differs from the vector", v.Share)
+		}
+	}
+	return nil
+}
+
+// runSelfTest checks this build against the given vectors. The results use
+// the same form as the doctor checks.
+func runSelfTest(data []byte) []doctorResult {
+	vectors, err := loadTestVectors(data)
+	if err != nil {
+		return []doctorResult{{"vectors", doctorFail, err.Error()}}
+	}
+
+	checks := []struct {
+		name  string
+		count int
+		check func(testVectors) error
+	}{
+		{"field", len(vectors.Field.Mul) + len(vectors.Field.Inverse), checkFieldVectors},
+		{"split", len(vectors.Split), checkSplitVectors},
+		{"combine", len(vectors.Combine), checkCombineVectors},
+		{"encoding", len(vectors.Encoding), checkEncodingVectors},
+	}
+	results := make([]doctorResult, 0, len(checks))
+	for _, c := range checks {
+		if err := c.check(vectors); err != nil {
+			results = append(results, doctorResult{c.name, doctorFail, err.Error()})
+		} else {
+			results = append(results, doctorResult{c.name, doctorPass, fmt.Sprintf("%d vectors", c.count)})
+		}
+	}
+	return results
+}
+
+// selfTest runs the embedded vectors and returns an error describing the
+// first failure.
+func selfTest() error {
+	for _, result := range runSelfTest(testVectorsJSON) {
+		if result.Status == doctorFail {
+			return sss.NewError(sss.ErrVerificationFailed, fmt.Sprintf("self-test failed: %s: %s", result.Check, result.Detail), nil)
+		}
+	}
+	return nil
+}
+
+func selftestCommand(flags *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		if len(args) != 0 {
+			return failWith(errUsage, "selftest takes no arguments")
+		}
+
+		results := runSelfTest(testVectorsJSON)
+		for _, result := range results {
+			fmt.Printf("%s  %-10s  %s\n", result.Status, result.Check, result.Detail)
+		}
+		status, summary := doctorSummary(results)
+		fmt.Println(summary)
+		if status == doctorFail {
+			return exitCode(sss.ErrVerificationFailed)
+		}
+		return exitOK
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/vault/shamir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	for _, result := range runSelfTest(testVectorsJSON) {
+		require.Equal(t, doctorPass, result.Status, "%s: %s", result.Check, result.Detail)
+	}
+	require.NoError(t, selfTest())
+
+	type testCase struct {
+		name   string
+		old    string
+		new    string
+		failed string
+	}
+
+	testCases := []testCase{
+		{name: "version", old: `"version": 1`, new: `"version": 2`, failed: "vectors"},
+		{name: "field", old: `[87, 131, 193]`, new: `[87, 131, 194]`, failed: "field"},
+		{name: "split", old: `"1-6bfe"`, new: `"1-6bff"`, failed: "split"},
+		{name: "combine", old: `"secret": "shares made by shamir.py"`, new: `"secret": "shares made by shamir.go"`, failed: "combine"},
+		{name: "encoding", old: `"data": "6bfe"`, new: `"data": "6bff"`, failed: "encoding"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Contains(t, string(testVectorsJSON), tc.old)
+			tampered := bytes.Replace(testVectorsJSON, []byte(tc.old), []byte(tc.new), 1)
+
+			var failed []string
+			for _, result := range runSelfTest(tampered) {
+				if result.Status == doctorFail {
+					failed = append(failed, result.Check)
+				}
+			}
+			require.Equal(t, []string{tc.failed}, failed)
+		})
+	}
+}
+
+// TestSplitVectorsWithVault checks the split vectors against an independent
+// implementation, so they do not merely record what this one produces.
+func TestSplitVectorsWithVault(t *testing.T) {
+	vectors, err := loadTestVectors(testVectorsJSON)
+	require.NoError(t, err)
+
+	for _, v := range vectors.Split {
+		if v.Compact {
+			continue
+		}
+		t.Run(v.Name, func(t *testing.T) {
+			shares := make([][]byte, 0, v.Threshold)
+			for _, encoded := range v.Shares[len(v.Shares)-v.Threshold:] {
+				_, share, err := decodeShare(encoded)
+				require.NoError(t, err)
+				shares = append(shares, share)
+			}
+			secret, err := shamir.Combine(shares)
+			require.NoError(t, err)
+			if v.Pad != "" {
+				secret, err = unpadPayload(secret)
+				require.NoError(t, err)
+			}
+			require.Equal(t, v.Secret, string(secret))
+		})
+	}
+}
@@ -2,109 +2,646 @@ package main
 
 import (
 	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"os/exec"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/hashicorp/vault/shamir"
+	"github.com/tofel/shamir/sss"
 )
 
-func splitSecret(secret string, totalShares int, threshold int) (string, error) {
-	shares, err := shamir.Split([]byte(secret), totalShares, threshold)
+// Share modes recorded in the share metadata. Shares without a mode carry the
+// secret as is and keep the plain "N-hex" format understood by shamir.py.
+const (
+	shareModeRaw   = ""
+	shareModeBIP39 = "bip39"
+)
+
+func splitPayload(random io.Reader, payload []byte, mode string, totalShares int, threshold int) (string, error) {
+	shares, err := sss.SplitFrom(random, payload, totalShares, threshold)
 	if err != nil {
 		return "", err
 	}
+	defer wipeShares(shares)
+
+	return encodeShares(mode, shares), nil
+}
+
+// splitPrepared splits a normalized secret the way the split flags ask for:
+// as is or as compact mnemonic entropy, optionally padded to hide its length.
+// It also returns the share mode.
+func splitPrepared(random io.Reader, secret []byte, secretType string, compact bool, pad string, totalShares int, threshold int) (string, string, error) {
+	payload, mode := secret, shareModeRaw
+	if compact {
+		compactPayload, err := mnemonicPayload(secret)
+		if err != nil {
+			return "", "", err
+		}
+		defer compactPayload.Destroy()
+		payload, mode = compactPayload.Bytes(), shareModeBIP39
+	}
+
+	if pad != "" {
+		size, err := padSize(pad, len(payload), secretType, compact)
+		if err != nil {
+			return "", "", err
+		}
+		padded, err := padPayload(payload, size)
+		if err != nil {
+			return "", "", err
+		}
+		defer padded.Destroy()
+		payload, mode = padded.Bytes(), withPadding(mode)
+	}
+
+	encoded, err := splitPayload(random, payload, mode, totalShares, threshold)
+	return encoded, mode, err
+}
+
+func wipeShares(shares [][]byte) {
+	for _, share := range shares {
+		clear(share)
+	}
+}
+
+func encodeShare(index int, mode string, share []byte) string {
+	if mode == shareModeRaw {
+		return fmt.Sprintf("%d-%s", index, hex.EncodeToString(share))
+	}
+	return fmt.Sprintf("%d-%s-%s", index, mode, hex.EncodeToString(share))
+}
 
-	encodedShares := make([]string, totalShares)
+func encodeShares(mode string, shares [][]byte) string {
+	encodedShares := make([]string, len(shares))
 	for i, share := range shares {
-		idx := i
-		encodedShares[i] = fmt.Sprintf("%d-%s", idx+1, hex.EncodeToString(share))
+		encodedShares[i] = encodeShare(i+1, mode, share)
 	}
-	return strings.Join(encodedShares, ","), nil
+	return strings.Join(encodedShares, ",")
 }
 
-func restoreSecret(encodedShares string) (string, error) {
-	shareStrings := strings.Split(encodedShares, ",")
-	shares := make([][]byte, len(shareStrings))
+// decodeShare parses a single share in any of the shareEncodings.
+func decodeShare(encodedShare string) (mode string, share []byte, err error) {
+	_, record, err := decodeShareRecord(encodedShare)
+	return record.Mode, record.Data, err
+}
 
-	for i, shareStr := range shareStrings {
-		parts := strings.SplitN(shareStr, "-", 2)
-		if len(parts) != 2 {
-			return "", fmt.Errorf("invalid share format")
+// restoreSecret combines the encoded shares into a secure buffer, which the
+// caller must destroy. The shares are separated as by splitShareList and may
+// each use a different encoding, except that codex32 shares only combine
+// with each other. Decoded shares are wiped before returning.
+func restoreSecret(encodedShares string) (*secureBuffer, error) {
+	shareStrings := splitShareList(encodedShares)
+	if len(shareStrings) == 0 {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "no shares given", nil)
+	}
+	if slices.ContainsFunc(shareStrings, isCodex32Shares) {
+		if slices.ContainsFunc(shareStrings, func(share string) bool { return !isCodex32Shares(share) }) {
+			return nil, sss.NewError(sss.ErrInconsistentShares, "codex32 shares cannot be combined with shares in other encodings", nil)
 		}
-		share, err := hex.DecodeString(parts[1])
+		return combineCodex32(strings.Join(shareStrings, ","))
+	}
+	shares := make([][]byte, 0, len(shareStrings))
+	defer func() { wipeShares(shares) }()
+
+	// Only armored shares know their set and threshold.
+	var mode, setID string
+	threshold := 0
+	for i, shareStr := range shareStrings {
+		_, share, err := decodeShareRecord(shareStr)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		shares = append(shares, share.Data)
+		if i > 0 && share.Mode != mode {
+			return nil, sss.NewError(sss.ErrInconsistentShares, "shares use different modes", nil)
+		}
+		mode = share.Mode
+		if share.SetID != "" {
+			if setID != "" && share.SetID != setID {
+				return nil, sss.NewError(sss.ErrInconsistentShares, "shares come from different sets", nil)
+			}
+			setID = share.SetID
 		}
-		shares[i] = share
+		threshold = max(threshold, share.Threshold)
+	}
+	if len(shares) < threshold {
+		return nil, sss.NewError(sss.ErrInsufficientShares, fmt.Sprintf("the shares need %d shares, got %d", threshold, len(shares)), nil)
 	}
 
-	secret, err := shamir.Combine(shares)
+	size := 0
+	if len(shares[0]) > sss.ShareOverhead {
+		size = len(shares[0]) - sss.ShareOverhead
+	}
+	secret, err := newSecureBuffer(size)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if err := sss.CombineInto(secret.Bytes(), shares); err != nil {
+		secret.Destroy()
+		return nil, err
 	}
 
-	return string(secret), nil
+	base, padded := splitShareMode(mode)
+	if padded {
+		defer secret.Destroy()
+		payload, err := unpadPayload(secret.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if base != shareModeBIP39 {
+			return newSecureBufferFrom(payload)
+		}
+		return mnemonicFromPayload(payload)
+	}
+	if base == shareModeBIP39 {
+		defer secret.Destroy()
+		return mnemonicFromPayload(secret.Bytes())
+	}
+	return secret, nil
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run shamir.go <command> <args>")
-		os.Exit(1)
+// writeSecret prints a secret followed by a newline without converting it
+// to an immutable string first.
+func writeSecret(secret []byte) {
+	os.Stdout.Write(secret)
+	os.Stdout.Write([]byte{'\n'})
+}
+
+// restoreChecked restores a secret and validates it as secretType. A wrong or
+// partial set of shares still decodes to something, so typed secrets are
+// checked before anything is shown or handed over.
+func restoreChecked(encodedShares, secretType string) (*secureBuffer, error) {
+	restored, err := restoreSecret(encodedShares)
+	if err != nil {
+		return nil, err
 	}
+	return checkRestored(restored, secretType, isCodex32Shares(encodedShares))
+}
 
-	command := os.Args[1]
-	switch command {
-	case "split":
-		if len(os.Args) != 5 {
-			fmt.Println("Usage: go run shamir.go split <secret> <threshold> <total_shares>")
-			os.Exit(1)
+// checkRestored validates a restored secret as secretType and destroys it.
+// Entropy-only secrets, as carried by codex32 shares, become a mnemonic
+// for bip39.
+func checkRestored(restored *secureBuffer, secretType string, entropyOnly bool) (*secureBuffer, error) {
+	defer restored.Destroy()
+	if secretType == secretTypeBase64 {
+		// Binary secrets are only encoded for output.
+		return newSecureBufferFrom(restored.Bytes())
+	}
+	if secretType == secretTypeBIP39 && entropyOnly {
+		// Codex32 shares carry the entropy without any mode of their own.
+		mnemonic, err := mnemonicFromEntropy(restored.Bytes())
+		if err != nil {
+			return nil, sss.NewError(sss.ErrInsufficientShares, "restored secret is invalid, the shares may be wrong or insufficient", err)
 		}
-		secret := os.Args[2]
-		threshold := os.Args[3]
-		totalShares := os.Args[4]
+		defer clear(mnemonic)
+		return newSecureBufferFrom(mnemonic)
+	}
+
+	secret, err := normalizeSecret(restored.Bytes(), secretType)
+	if err != nil {
+		return nil, sss.NewError(sss.ErrInsufficientShares, "restored secret is invalid, the shares may be wrong or insufficient", err)
+	}
+	return secret, nil
+}
 
-		totalSharesInt, err := strconv.Atoi(totalShares)
+func splitCommand(flags *flag.FlagSet) func(args []string) int {
+	secretArg := flags.String("secret", "", "secret to split")
+	threshold := flags.Int("threshold", 0, "number of shares needed to restore the secret")
+	totalShares := flags.Int("total", 0, "number of shares to create")
+	secretType := flags.String("type", secretTypeText, "secret type: text, bip39 or base64")
+	compact := flags.Bool("compact", false, "split the entropy of a bip39 mnemonic instead of its words")
+	printFingerprint := flags.Bool("fingerprint", false, "also print a salted fingerprint of the secret")
+	harden := flags.Bool("harden", false, "Linux only: disable core dumps and ptrace, refuse world-readable output")
+	seccomp := flags.Bool("seccomp", false, "with --harden, also block network, ptrace and exec system calls")
+	output := flags.String("output", outputText, "output format: text or json")
+	entropyFile := flags.String("entropy-file", "", "read all randomness from this file or device instead of the system")
+	deterministicSeed := flags.String("insecure-deterministic-seed", "", "INSECURE, for tests only: derive all randomness from this seed")
+	generate := flags.String("generate", "", "generate the secret instead of reading it: bytes[:N] or bip39[:WORDS]")
+	dice := flags.Bool("dice", false, "with --generate, mix in dice rolls read from stdin")
+	pad := flags.String("pad", "", "pad the secret to hide its length: bucket or a length in bytes")
+	qr := flags.String("qr", "", "also render each share as a QR code: terminal or png")
+	qrDir := flags.String("qr-dir", ".", "directory for --qr png files")
+	printDir := flags.String("print", "", "write a printable sheet per shareholder to this directory")
+	printFormat := flags.String("print-format", printHTML, "format of --print sheets: html or svg")
+	holderList := flags.String("holders", "", "comma-separated shareholder names for --print sheets and armored shares")
+	format := flags.String("format", shareFormatHex, "share format: "+strings.Join(shareFormatNames(), ", "))
+	formats := newShareFormats()
+	formatFlags := setFormatFlags(flags, formats)
+
+	return func(args []string) int {
+		out, err := newReporter("split", *output)
 		if err != nil {
-			fmt.Println("Invalid total_shares value")
-			os.Exit(1)
+			return fail("Invalid arguments", err)
+		}
+		positional := []string{"secret", "threshold", "total"}
+		if *generate != "" {
+			positional = positional[1:]
+		}
+		if err := bindPositional(flags, len(positional), positional...); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+		if *generate != "" && *secretArg != "" {
+			return out.failWith(errUsage, "--generate cannot be combined with a secret")
+		}
+		if *dice && *generate == "" {
+			return out.failWith(errUsage, "--dice requires --generate")
+		}
+		switch {
+		case *qr != "" && *qr != qrTerminal && *qr != qrPNG:
+			return out.failWith(errUsage, fmt.Sprintf("unknown --qr format %q, expected terminal or png", *qr))
+		case *qr == qrTerminal && out.json():
+			return out.failWith(errUsage, "--qr terminal cannot be combined with --output json")
+		}
+		shareFormat, err := shareFormatByName(formats, *format)
+		if err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+		if err := checkFormatFlags(flags, formatFlags, shareFormat.name()); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+		if strings.ContainsAny(*holderList, "\r\n") {
+			return out.failWith(errUsage, "--holders must not contain line breaks")
+		}
+		if *printFormat != printHTML && *printFormat != printSVG {
+			return out.failWith(errUsage, fmt.Sprintf("unknown --print-format %q, expected html or svg", *printFormat))
+		}
+		holders, err := parseHolders(*holderList, *totalShares)
+		if err != nil {
+			return out.fail("Invalid arguments", sss.NewError(errUsage, "invalid --holders", err))
+		}
+		var spec generateSpec
+		if *generate != "" {
+			spec, err = parseGenerateSpec(*generate)
+			if err != nil {
+				return out.fail("Invalid arguments", err)
+			}
+			switch {
+			case spec.kind == generateBIP39:
+				*secretType = secretTypeBIP39
+			case *secretType == secretTypeBIP39:
+				return out.failWith(errUsage, "--type bip39 requires --generate bip39")
+			}
+		}
+		opts := splitOptions{
+			secretType:     *secretType,
+			compact:        *compact,
+			pad:            *pad,
+			generatedBytes: *generate != "" && spec.kind != generateBIP39,
+			threshold:      *threshold,
+			totalShares:    *totalShares,
+			print:          *printDir != "",
+			qr:             *qr != "",
+		}
+		if *holderList != "" {
+			opts.holders = holders
+		}
+		if err := shareFormat.check(opts); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+		if *harden || *seccomp {
+			if err := hardenProcess(*seccomp); err != nil {
+				return out.fail("Error hardening process", err)
+			}
+		}
+		if err := selfTest(); err != nil {
+			return out.fail("Refusing to split", err)
+		}
+
+		if *threshold > *totalShares {
+			return out.failWith(sss.ErrInvalidParameters, "Threshold cannot be bigger than total shares")
 		}
 
-		thresholdInt, err := strconv.Atoi(threshold)
+		if *compact && *secretType != secretTypeBIP39 {
+			return out.failWith(errUsage, "--compact requires --type bip39")
+		}
+
+		random, randomSource, closeRandom, err := openRandomSource(*entropyFile, *deterministicSeed)
 		if err != nil {
-			fmt.Println("Invalid threshold value")
-			os.Exit(1)
+			return out.fail("Invalid randomness source", err)
+		}
+		defer closeRandom()
+		if randomSource == randomSourceInsecureDeterministic {
+			fmt.Fprintln(os.Stderr, insecureDeterministicWarning)
 		}
 
-		if thresholdInt > totalSharesInt {
-			fmt.Println("Threshold cannot be bigger than total shares")
-			os.Exit(1)
+		var secret *secureBuffer
+		var rolls []byte
+		if *generate != "" {
+			if *dice {
+				rolls, err = readDiceRolls(os.Stdin, os.Stderr, diceRollsNeeded(spec.entropySize()))
+				if err != nil {
+					return out.fail("Error reading dice rolls", err)
+				}
+				defer clear(rolls)
+			}
+			secret, err = generateSecret(random, spec, rolls)
+			if err != nil {
+				return out.fail("Error generating secret", err)
+			}
+		} else {
+			// The argument itself is an immutable string owned by the runtime;
+			// only the copies made from here on can be locked and wiped.
+			raw := []byte(*secretArg)
+			secret, err = normalizeSecret(raw, *secretType)
+			clear(raw)
+			if err != nil {
+				return out.fail("Invalid secret", sss.NewError(sss.ErrInvalidSecret, "secret rejected", err))
+			}
 		}
+		defer secret.Destroy()
 
-		encoded, err := splitSecret(secret, totalSharesInt, thresholdInt)
+		shares, err := shareFormat.split(random, secret.Bytes(), opts)
 		if err != nil {
-			fmt.Printf("Error splitting secret: %v\n", err)
-			os.Exit(1)
+			return out.fail("Error splitting secret", err)
 		}
-		fmt.Println(encoded)
+		encoded := shares.encoded
 
-	case "restore":
-		if len(os.Args) != 3 {
-			fmt.Println("Usage: go run shamir.go restore <encoded_shares>")
-			os.Exit(1)
+		var fingerprint string
+		if *printFingerprint {
+			fingerprint, err = secretFingerprint(random, secret.Bytes())
+			if err != nil {
+				return out.fail("Error computing fingerprint", err)
+			}
 		}
-		encodedShares := os.Args[2]
 
-		secret, err := restoreSecret(encodedShares)
+		qrFiles := make(map[int]string)
+		if *qr == qrPNG {
+			for i, share := range strings.Split(encoded, ",") {
+				path, err := writeShareQRPNG(*qrDir, i+1, share)
+				if err != nil {
+					return out.fail("Error writing QR code", err)
+				}
+				qrFiles[i+1] = path
+			}
+		}
+
+		var sheetFiles []string
+		if *printDir != "" {
+			sheetFiles, err = writeShareSheets(*printDir, *printFormat, encoded, shares.mode, *threshold, holders)
+			if err != nil {
+				return out.fail("Error writing share sheets", err)
+			}
+		}
+
+		if !out.json() {
+			if *generate != "" {
+				fmt.Fprintf(os.Stderr, "Generated a new %s secret. It exists only in these shares.\n", spec)
+			}
+			if *qr == qrTerminal {
+				for i, share := range strings.Split(encoded, ",") {
+					matrix, err := shareQRMatrix(share)
+					if err != nil {
+						return out.fail("Error rendering QR code", err)
+					}
+					fmt.Printf("Share %d of %d\n%s%s\n\n", i+1, *totalShares, renderQRTerminal(matrix), share)
+				}
+			} else if err := shareFormat.write(os.Stdout, shares, opts); err != nil {
+				return out.fail("Error encoding shares", err)
+			}
+			for i := 1; i <= len(qrFiles); i++ {
+				fmt.Fprintf(os.Stderr, "Wrote %s\n", qrFiles[i])
+			}
+			for _, path := range sheetFiles {
+				fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+			}
+			if len(sheetFiles) > 0 {
+				fmt.Fprintf(os.Stderr, "Set ID: %s\n", shareSetID(encoded))
+			}
+			if fingerprint != "" {
+				fmt.Printf("Fingerprint: %s\n", fingerprint)
+			}
+			return exitOK
+		}
+
+		result := splitResult{
+			jsonHeader:        out.header(),
+			SecretType:        *secretType,
+			Mode:              shareModeName(shares.mode),
+			Threshold:         *threshold,
+			TotalShares:       *totalShares,
+			SetID:             shareSetID(encoded),
+			RandomSource:      randomSource,
+			DiceRolls:         len(rolls),
+			Generated:         generatedName(*generate, spec),
+			SecretFingerprint: fingerprint,
+		}
+		for _, encodedShare := range strings.Split(encoded, ",") {
+			share, data, err := shareFormat.describe(encodedShare)
+			if err != nil {
+				return out.fail("Error describing share", err)
+			}
+			clear(data)
+			share.Label = fmt.Sprintf("Share %d of %d", share.Index, *totalShares)
+			share.Mode = shareModeName(shares.mode)
+			if shares.formatted != nil {
+				share.Encoding, share.Payload = shareFormat.name(), shares.formatted[share.Index-1]
+			}
+			share.QRFile = qrFiles[share.Index]
+			if share.Index <= len(sheetFiles) {
+				share.SheetFile = sheetFiles[share.Index-1]
+			}
+			result.Shares = append(result.Shares, share)
+		}
+		out.emit(result)
+		return exitOK
+	}
+}
+
+func restoreCommand(flags *flag.FlagSet) func(args []string) int {
+	sharesArg := flags.String("shares", "", "encoded shares in any encoding, separated by commas or line breaks")
+	secretType := flags.String("type", secretTypeText, "secret type: text, bip39 or base64")
+	verifyBIP32 := flags.Bool("verify-bip32", false, "print wallet fingerprint, xpubs and addresses instead of the mnemonic")
+	addressCount := flags.Int("addresses", 3, "number of addresses to derive per standard with --verify-bip32")
+	askPassphrase := flags.Bool("passphrase", false, "prompt for a BIP-39 passphrase with --verify-bip32")
+	verifyOnly := flags.Bool("verify-only", false, "only report whether the secret matches --fingerprint")
+	fingerprint := flags.String("fingerprint", "", "fingerprint printed by split --fingerprint, checked before any output")
+	execCommand := flags.String("exec", "", "pass the secret to this shell command instead of printing it")
+	execVia := flags.String("exec-via", execViaStdin, "how --exec receives the secret: stdin, fd or env")
+	execEnv := flags.String("exec-env", execDefaultEnv, "environment variable used with --exec-via env")
+	harden := flags.Bool("harden", false, "Linux only: disable core dumps and ptrace, refuse world-readable output")
+	seccomp := flags.Bool("seccomp", false, "with --harden, also block network, ptrace and exec system calls")
+	output := flags.String("output", outputText, "output format: text or json")
+	var fromQR, fromFile pathList
+	flags.Var(&fromQR, "from-qr", "read shares from the QR codes in this PNG or JPEG image, can be repeated")
+	flags.Var(&fromFile, "from-file", "read shares from this file, one per line or armored, - for stdin, can be repeated")
+	interactive := flags.Bool("interactive", false, "type the shares at a prompt, line by line with their check codes")
+	ssss := flags.Bool("ssss", false, "combine shares of ssss-split, as [token-]index-hex")
+	token := flags.String("token", "", "with --ssss, the name every share must be prefixed with")
+	threshold := flags.Int("threshold", 0, "with --ssss, the threshold the shares were split with (required)")
+
+	return func(args []string) int {
+		out, err := newReporter("restore", *output)
 		if err != nil {
-			fmt.Printf("Error restoring secret: %v\n", err)
-			os.Exit(1)
+			return fail("Invalid arguments", err)
+		}
+		required := 1
+		if len(fromQR) > 0 || len(fromFile) > 0 || *interactive {
+			required = 0
+		}
+		if err := bindPositional(flags, required, "shares"); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+		switch {
+		case (*token != "" || *threshold != 0) && !*ssss:
+			return out.failWith(errUsage, "--token and --threshold require --ssss")
+		case *ssss && *interactive:
+			return out.failWith(errUsage, "--ssss cannot be combined with --interactive")
+		case *ssss && *threshold == 0:
+			return out.failWith(errUsage, "--ssss requires --threshold, ssss shares do not record their threshold")
+		case *ssss && *threshold < 0:
+			return out.failWith(errUsage, "--threshold must be positive")
+		}
+		for _, path := range fromQR {
+			shares, err := readQRShares(path)
+			if err != nil {
+				return out.fail("Error reading QR code", err)
+			}
+			if *sharesArg != "" {
+				shares = append([]string{*sharesArg}, shares...)
+			}
+			*sharesArg = strings.Join(shares, ",")
+		}
+		if len(fromFile) > 0 {
+			shares, err := readShareFiles(fromFile)
+			if err != nil {
+				return out.fail("Error reading shares", err)
+			}
+			if *sharesArg != "" {
+				shares = append([]string{*sharesArg}, shares...)
+			}
+			*sharesArg = strings.Join(shares, ",")
+		}
+		if *interactive {
+			shares, err := collectShares(os.Stdin, os.Stderr)
+			if err != nil {
+				return out.fail("Error reading shares", err)
+			}
+			if *sharesArg != "" {
+				shares = append([]string{*sharesArg}, shares...)
+			}
+			*sharesArg = strings.Join(shares, ",")
+		}
+		if *seccomp && *execCommand != "" {
+			return out.failWith(errUsage, "--seccomp cannot be combined with --exec, the filter blocks starting programs")
+		}
+		if *execCommand != "" && (*verifyOnly || *verifyBIP32) {
+			return out.failWith(errUsage, "--exec cannot be combined with --verify-only or --verify-bip32")
+		}
+		if *execCommand != "" && out.json() {
+			return out.failWith(errUsage, "--exec cannot be combined with --output json")
+		}
+		if *verifyOnly && *fingerprint == "" {
+			return out.failWith(errUsage, "--verify-only requires --fingerprint")
+		}
+		if *verifyOnly && *verifyBIP32 {
+			return out.failWith(errUsage, "--verify-only cannot be combined with --verify-bip32")
+		}
+		if *harden || *seccomp {
+			if err := hardenProcess(*seccomp); err != nil {
+				return out.fail("Error hardening process", err)
+			}
 		}
-		fmt.Println(secret)
 
-	default:
-		fmt.Println("Invalid command. Use 'split' or 'restore'")
-		os.Exit(1)
+		if *verifyBIP32 {
+			*secretType = secretTypeBIP39
+		}
+		var secret *secureBuffer
+		if *ssss {
+			var restored *secureBuffer
+			restored, err = combineSSSS(*sharesArg, *token, *threshold)
+			if err == nil {
+				secret, err = checkRestored(restored, *secretType, false)
+			}
+		} else {
+			secret, err = restoreChecked(*sharesArg, *secretType)
+		}
+		if err != nil {
+			return out.fail("Error restoring secret", err)
+		}
+		defer secret.Destroy()
+
+		result := restoreResult{jsonHeader: out.header(), SecretType: *secretType}
+		if *fingerprint != "" {
+			matches, err := matchFingerprint(secret.Bytes(), *fingerprint)
+			if err != nil {
+				return out.fail("Error checking fingerprint", err)
+			}
+			if !matches {
+				return out.failWith(sss.ErrVerificationFailed, "Fingerprint does not match")
+			}
+			result.FingerprintMatch = &matches
+			if *verifyOnly {
+				if out.json() {
+					out.emit(result)
+				} else {
+					fmt.Println("Fingerprint matches")
+				}
+				return exitOK
+			}
+		}
+
+		if *secretType == secretTypeBase64 {
+			encoded, err := encodeBase64Secret(secret.Bytes())
+			if err != nil {
+				return out.fail("Error encoding secret", err)
+			}
+			defer encoded.Destroy()
+			secret = encoded
+		}
+
+		if *execCommand != "" {
+			err := runWithSecret(*execCommand, secret.Bytes(), *execVia, *execEnv)
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return childExitCode(exitErr)
+			}
+			if err != nil {
+				return out.fail("Error running command", err)
+			}
+			return exitOK
+		}
+
+		if !*verifyBIP32 {
+			if !out.json() {
+				writeSecret(secret.Bytes())
+				return exitOK
+			}
+			// JSON output necessarily copies the secret into ordinary memory.
+			if utf8.Valid(secret.Bytes()) {
+				result.Secret, result.SecretEncoding = string(secret.Bytes()), "utf-8"
+			} else {
+				result.Secret, result.SecretEncoding = hex.EncodeToString(secret.Bytes()), "hex"
+			}
+			out.emit(result)
+			return exitOK
+		}
+
+		var passphrase string
+		if *askPassphrase {
+			passphrase, err = readPassphrase()
+			if err != nil {
+				return out.fail("Error reading passphrase", err)
+			}
+		}
+		summary, err := deriveWalletSummary(secret.Bytes(), passphrase, *addressCount)
+		if err != nil {
+			return out.fail("Error deriving wallet", err)
+		}
+		if out.json() {
+			result.Wallet = &summary
+			out.emit(result)
+		} else {
+			fmt.Println(formatWalletSummary(summary))
+		}
+		return exitOK
 	}
 }
+
+func main() {
+	os.Exit(runCLI(os.Args[1:]))
+}
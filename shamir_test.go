@@ -1,6 +1,7 @@
 package main
 
 import (
+	cryptorand "crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
@@ -51,19 +52,19 @@ func TestSplitAndRestoreSecret(t *testing.T) {
 			threshold := tc.threshold
 			totalShares := tc.totalShares
 
-			encodedShares, err := splitSecret(secret, totalShares, threshold)
+			encodedShares, _, err := splitPrepared(cryptorand.Reader, []byte(secret), secretTypeText, false, "", totalShares, threshold)
 			require.NoError(t, err, "splitting should not fail")
 
 			restoredSecret, err := restoreSecret(encodedShares)
 			require.NoError(t, err, "restoring secret from all shares should not fail")
-			require.Equal(t, secret, restoredSecret, "restored secret should be the same as original one")
+			require.Equal(t, secret, string(restoredSecret.Bytes()), "restored secret should be the same as original one")
 
 			shuffledShares := shuffleShares(encodedShares)
 			require.NotEqual(t, encodedShares, shuffledShares, "shuffled shares should be different from original ones")
 
 			restoredSecret, err = restoreSecret(shuffledShares)
 			require.NoError(t, err, "restoring secret from shuffled shares should not fail")
-			require.Equal(t, secret, restoredSecret, "restored secret should be the same as original one")
+			require.Equal(t, secret, string(restoredSecret.Bytes()), "restored secret should be the same as original one")
 
 			for i := 1; i < totalShares; i++ {
 				if i < threshold {
@@ -74,13 +75,13 @@ func TestSplitAndRestoreSecret(t *testing.T) {
 					if i == 1 {
 						require.Error(t, err, "restoring secret with 1 share should fail")
 					} else {
-						require.NotEqual(t, secret, restoredSecret, "restoring secret with insufficient shares should result in incorrect secret")
+						require.NotEqual(t, secret, string(restoredSecret.Bytes()), "restoring secret with insufficient shares should result in incorrect secret")
 					}
 				} else {
 					suffcientShares := getNshares(encodedShares, i)
 					restoredSecret, err = restoreSecret(suffcientShares)
 					require.NoError(t, err, "restoring shares with shares >= threshold should not fail")
-					require.Equal(t, secret, restoredSecret, "restored secret should be the same as original one")
+					require.Equal(t, secret, string(restoredSecret.Bytes()), "restored secret should be the same as original one")
 				}
 			}
 
@@ -136,7 +137,7 @@ func TestIncorrectSplit(t *testing.T) {
 			threshold := tc.threshold
 			totalShares := tc.totalShares
 
-			_, err := splitSecret("my_secret", totalShares, threshold)
+			_, _, err := splitPrepared(cryptorand.Reader, []byte("my_secret"), secretTypeText, false, "", totalShares, threshold)
 			require.Error(t, err, "splitting with incorrect inputs should fail")
 		})
 	}
@@ -0,0 +1,61 @@
+package sss
+
+import "errors"
+
+// Every error returned by this package is an *Error whose Kind is one of the
+// Err values below, so callers can tell failure classes apart with errors.Is
+// without matching on messages. The shamir command maps each class to its
+// own exit code.
+var (
+	// ErrInvalidParameters reports a threshold or share count out of range.
+	ErrInvalidParameters = errors.New("invalid parameters")
+	// ErrInvalidSecret reports a secret that cannot be split, such as an
+	// empty one or a mnemonic with a bad checksum.
+	ErrInvalidSecret = errors.New("invalid secret")
+	// ErrMalformedShare reports a share that cannot be decoded or has the
+	// wrong length.
+	ErrMalformedShare = errors.New("malformed share")
+	// ErrDuplicateShare reports the same share given twice.
+	ErrDuplicateShare = errors.New("duplicate share")
+	// ErrInconsistentShares reports shares that cannot belong to the same
+	// split, for example because they use different encodings.
+	ErrInconsistentShares = errors.New("inconsistent shares")
+	// ErrInsufficientShares reports fewer shares than needed. Combine only
+	// detects fewer than two; a restore below the threshold is usually caught
+	// later, when the result fails a checksum or validation.
+	ErrInsufficientShares = errors.New("insufficient shares")
+	// ErrVerificationFailed reports a restored secret that does not match
+	// what was expected of it.
+	ErrVerificationFailed = errors.New("verification failed")
+	// ErrRandomness reports a failure of the random source.
+	ErrRandomness = errors.New("random source failed")
+)
+
+// Error is a failure of a given class.
+type Error struct {
+	Kind error
+	Msg  string
+	Err  error
+}
+
+// NewError returns an *Error of the given kind. It is exported so that
+// code built on this package can report its failures in the same classes.
+func NewError(kind error, msg string, err error) *Error {
+	return &Error{Kind: kind, Msg: msg, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Msg + ": " + e.Err.Error()
+	}
+	return e.Msg
+}
+
+// Is reports whether target is the class of e.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,23 @@
+package sss
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("short read")
+	err := fmt.Errorf("restore: %w", NewError(ErrRandomness, "failed to generate polynomial", cause))
+
+	require.ErrorIs(t, err, ErrRandomness, "kind should match through wrapping")
+	require.ErrorIs(t, err, cause, "cause should be unwrapped")
+	require.NotErrorIs(t, err, ErrMalformedShare)
+	require.Equal(t, "restore: failed to generate polynomial: short read", err.Error())
+
+	var sssErr *Error
+	require.True(t, errors.As(err, &sssErr))
+	require.Equal(t, ErrRandomness, sssErr.Kind)
+}
@@ -0,0 +1,89 @@
+package sss
+
+import "crypto/subtle"
+
+// Arithmetic in GF(2^8) using the same reduction polynomial
+// (x^8 + x^4 + x^3 + x + 1) as hashicorp/vault/shamir. None of the
+// operations branch on or index by their operands.
+
+// add combines two numbers in GF(2^8). It is also subtraction.
+func add(a, b uint8) uint8 {
+	return a ^ b
+}
+
+// mult multiplies two numbers in GF(2^8).
+func mult(a, b uint8) uint8 {
+	var r uint8
+	for i := 8; i > 0; i-- {
+		r = (-(b >> (i - 1) & 1) & a) ^ (-(r >> 7) & 0x1B) ^ (r + r)
+	}
+	return r
+}
+
+// inverse returns the multiplicative inverse of a as a^254.
+func inverse(a uint8) uint8 {
+	b := mult(a, a)
+	c := mult(a, b)
+	b = mult(c, c)
+	b = mult(b, b)
+	c = mult(b, c)
+	b = mult(b, b)
+	b = mult(b, b)
+	b = mult(b, c)
+	b = mult(b, b)
+	b = mult(a, b)
+	return mult(b, b)
+}
+
+// div divides a by b. b must not be zero.
+func div(a, b uint8) uint8 {
+	if b == 0 {
+		panic("divide by zero")
+	}
+	ret := int(mult(a, inverse(b)))
+	return uint8(subtle.ConstantTimeSelect(subtle.ConstantTimeByteEq(a, 0), 0, ret))
+}
+
+// evaluate returns the value at x of the polynomial whose coefficients are
+// given from the constant term upwards, using Horner's method.
+func evaluate(coefficients []uint8, x uint8) uint8 {
+	if x == 0 {
+		return coefficients[0]
+	}
+	out := coefficients[len(coefficients)-1]
+	for i := len(coefficients) - 2; i >= 0; i-- {
+		out = add(mult(out, x), coefficients[i])
+	}
+	return out
+}
+
+// interpolate returns the value at x of the polynomial through the given
+// sample points, using Lagrange interpolation.
+func interpolate(xSamples, ySamples []uint8, x uint8) uint8 {
+	var result uint8
+	for i := range xSamples {
+		basis := uint8(1)
+		for j := range xSamples {
+			if i == j {
+				continue
+			}
+			num := add(x, xSamples[j])
+			denom := add(xSamples[i], xSamples[j])
+			basis = mult(basis, div(num, denom))
+		}
+		result = add(result, mult(ySamples[i], basis))
+	}
+	return result
+}
+
+// Mul multiplies a and b in GF(2^8). It is exported so that callers can check
+// the field arithmetic against known-answer vectors.
+func Mul(a, b uint8) uint8 {
+	return mult(a, b)
+}
+
+// Inverse returns the multiplicative inverse of a in GF(2^8), or zero for
+// zero. Like Mul, it is exported for known-answer tests.
+func Inverse(a uint8) uint8 {
+	return inverse(a)
+}
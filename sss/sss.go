@@ -0,0 +1,193 @@
+// Package sss implements Shamir's Secret Sharing over GF(2^8).
+//
+// Shares use the same field and layout as hashicorp/vault/shamir: the
+// evaluations of one polynomial per secret byte, followed by a single byte
+// holding the x coordinate. Shares produced by either implementation can be
+// combined by the other. Unlike the vault package, every intermediate buffer
+// derived from the secret, such as polynomial coefficients and interpolation
+// samples, is zeroed before returning.
+package sss
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// ShareOverhead is the number of bytes each share adds to the secret length.
+const ShareOverhead = 1
+
+// Split divides secret into parts shares, any threshold of which reconstruct
+// it. Every share gets a distinct random x coordinate.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	return SplitFrom(rand.Reader, secret, parts, threshold)
+}
+
+// SplitFrom is like Split, but reads all randomness, for both the x
+// coordinates and the polynomial coefficients, from random instead of
+// crypto/rand. The shares are only as secure as random is unpredictable; a
+// fixed stream makes the output reproducible, which is meant for tests.
+func SplitFrom(random io.Reader, secret []byte, parts, threshold int) ([][]byte, error) {
+	if err := checkSplitParams(secret, parts, threshold); err != nil {
+		return nil, err
+	}
+
+	xCoordinates, err := randomCoordinates(random, parts)
+	if err != nil {
+		return nil, err
+	}
+	return split(random, secret, xCoordinates, threshold)
+}
+
+// SplitWithCoordinates is like Split, but evaluates the polynomials at the
+// given x coordinates, which must be distinct and non-zero.
+func SplitWithCoordinates(secret []byte, xCoordinates []byte, threshold int) ([][]byte, error) {
+	return SplitWithCoordinatesFrom(rand.Reader, secret, xCoordinates, threshold)
+}
+
+// SplitWithCoordinatesFrom is like SplitWithCoordinates, but reads the
+// polynomial coefficients from random instead of crypto/rand.
+func SplitWithCoordinatesFrom(random io.Reader, secret []byte, xCoordinates []byte, threshold int) ([][]byte, error) {
+	if err := checkSplitParams(secret, len(xCoordinates), threshold); err != nil {
+		return nil, err
+	}
+
+	var seen [256]bool
+	for _, x := range xCoordinates {
+		if x == 0 {
+			return nil, NewError(ErrInvalidParameters, "x coordinates cannot be zero", nil)
+		}
+		if seen[x] {
+			return nil, NewError(ErrInvalidParameters, "x coordinates must be distinct", nil)
+		}
+		seen[x] = true
+	}
+	return split(random, secret, xCoordinates, threshold)
+}
+
+// Combine reconstructs a secret from shares produced by Split. Fewer shares
+// than the threshold produce a wrong secret rather than an error.
+func Combine(parts [][]byte) ([]byte, error) {
+	if err := checkCombineParams(parts); err != nil {
+		return nil, err
+	}
+	secret := make([]byte, len(parts[0])-ShareOverhead)
+	if err := CombineInto(secret, parts); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// CombineInto is like Combine, but writes the secret into dst, which must be
+// exactly ShareOverhead bytes shorter than each part. This lets callers keep
+// the secret in memory they manage themselves.
+func CombineInto(dst []byte, parts [][]byte) error {
+	if err := checkCombineParams(parts); err != nil {
+		return err
+	}
+	size := len(parts[0]) - ShareOverhead
+	if len(dst) != size {
+		return NewError(ErrInvalidParameters, "destination must be one byte shorter than each part", nil)
+	}
+
+	xSamples := make([]uint8, len(parts))
+	ySamples := make([]uint8, len(parts))
+	defer clear(ySamples)
+
+	var seen [256]bool
+	for i, part := range parts {
+		x := part[size]
+		if seen[x] {
+			return NewError(ErrDuplicateShare, "duplicate part detected", nil)
+		}
+		seen[x] = true
+		xSamples[i] = x
+	}
+
+	for idx := range dst {
+		for i, part := range parts {
+			ySamples[i] = part[idx]
+		}
+		dst[idx] = interpolate(xSamples, ySamples, 0)
+	}
+	return nil
+}
+
+func checkSplitParams(secret []byte, parts, threshold int) error {
+	if parts < threshold {
+		return NewError(ErrInvalidParameters, "parts cannot be less than threshold", nil)
+	}
+	if parts > 255 {
+		return NewError(ErrInvalidParameters, "parts cannot exceed 255", nil)
+	}
+	if threshold < 2 {
+		return NewError(ErrInvalidParameters, "threshold must be at least 2", nil)
+	}
+	if threshold > 255 {
+		return NewError(ErrInvalidParameters, "threshold cannot exceed 255", nil)
+	}
+	if len(secret) == 0 {
+		return NewError(ErrInvalidSecret, "cannot split an empty secret", nil)
+	}
+	return nil
+}
+
+func checkCombineParams(parts [][]byte) error {
+	if len(parts) < 2 {
+		return NewError(ErrInsufficientShares, "less than two parts cannot be used to reconstruct the secret", nil)
+	}
+	firstPartLen := len(parts[0])
+	if firstPartLen < 2 {
+		return NewError(ErrMalformedShare, "parts must be at least two bytes", nil)
+	}
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) != firstPartLen {
+			return NewError(ErrInconsistentShares, "all parts must be the same length", nil)
+		}
+	}
+	return nil
+}
+
+// randomCoordinates picks n distinct non-zero x coordinates.
+func randomCoordinates(random io.Reader, n int) ([]byte, error) {
+	var seen [256]bool
+	out := make([]byte, 0, n)
+	buf := make([]byte, 64)
+	for len(out) < n {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, NewError(ErrRandomness, "failed to generate x coordinates", err)
+		}
+		for _, x := range buf {
+			if x != 0 && !seen[x] && len(out) < n {
+				seen[x] = true
+				out = append(out, x)
+			}
+		}
+	}
+	return out, nil
+}
+
+func split(random io.Reader, secret []byte, xCoordinates []byte, threshold int) ([][]byte, error) {
+	out := make([][]byte, len(xCoordinates))
+	for i, x := range xCoordinates {
+		out[i] = make([]byte, len(secret)+ShareOverhead)
+		out[i][len(secret)] = x
+	}
+
+	// One random polynomial per secret byte, with the byte as its intercept.
+	// The buffer is reused for every byte and wiped when done.
+	coefficients := make([]uint8, threshold)
+	defer clear(coefficients)
+	for idx, val := range secret {
+		coefficients[0] = val
+		if _, err := io.ReadFull(random, coefficients[1:]); err != nil {
+			for _, share := range out {
+				clear(share)
+			}
+			return nil, NewError(ErrRandomness, "failed to generate polynomial", err)
+		}
+		for i, x := range xCoordinates {
+			out[i][idx] = evaluate(coefficients, x)
+		}
+	}
+	return out, nil
+}
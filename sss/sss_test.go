@@ -0,0 +1,161 @@
+package sss
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/hashicorp/vault/shamir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAndCombine(t *testing.T) {
+	type testCase struct {
+		name      string
+		secret    []byte
+		parts     int
+		threshold int
+	}
+
+	testCases := []testCase{
+		{
+			name:      "single byte",
+			secret:    []byte{0x42},
+			parts:     2,
+			threshold: 2,
+		},
+		{
+			name:      "word",
+			secret:    []byte("my_secret"),
+			parts:     5,
+			threshold: 3,
+		},
+		{
+			name:      "maximum parts",
+			secret:    []byte("god of mices ate my food"),
+			parts:     255,
+			threshold: 255,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shares, err := Split(tc.secret, tc.parts, tc.threshold)
+			require.NoError(t, err, "splitting should not fail")
+			require.Len(t, shares, tc.parts)
+
+			xs := map[byte]bool{}
+			for _, share := range shares {
+				require.Len(t, share, len(tc.secret)+ShareOverhead)
+				x := share[len(share)-1]
+				require.NotZero(t, x, "x coordinate should not be zero")
+				require.False(t, xs[x], "x coordinates should be distinct")
+				xs[x] = true
+			}
+
+			secret, err := Combine(shares[len(shares)-tc.threshold:])
+			require.NoError(t, err, "combining threshold shares should not fail")
+			require.Equal(t, tc.secret, secret)
+
+			dst := make([]byte, len(tc.secret))
+			require.NoError(t, CombineInto(dst, shares))
+			require.Equal(t, tc.secret, dst)
+
+			require.Error(t, CombineInto(make([]byte, len(tc.secret)+1), shares), "wrong destination size should fail")
+		})
+	}
+}
+
+func TestVaultCompatibility(t *testing.T) {
+	secret := []byte("pen aunt text rotate donate sock shield pottery")
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	restored, err := shamir.Combine(shares[1:4])
+	require.NoError(t, err, "vault should combine our shares")
+	require.Equal(t, secret, restored)
+
+	vaultShares, err := shamir.Split(secret, 5, 3)
+	require.NoError(t, err)
+	restored, err = Combine(vaultShares[2:])
+	require.NoError(t, err, "vault shares should combine")
+	require.Equal(t, secret, restored)
+}
+
+func TestSplitWithCoordinates(t *testing.T) {
+	secret := []byte("my_secret")
+
+	shares, err := SplitWithCoordinates(secret, []byte{1, 2, 3}, 2)
+	require.NoError(t, err)
+	for i, share := range shares {
+		require.Equal(t, byte(i+1), share[len(share)-1], "shares should use the given coordinates")
+	}
+	restored, err := Combine(shares[:2])
+	require.NoError(t, err)
+	require.Equal(t, secret, restored)
+
+	_, err = SplitWithCoordinates(secret, []byte{1, 0, 3}, 2)
+	require.Error(t, err, "zero coordinate should fail")
+
+	_, err = SplitWithCoordinates(secret, []byte{1, 2, 1}, 2)
+	require.Error(t, err, "duplicate coordinates should fail")
+}
+
+func TestSplitFrom(t *testing.T) {
+	secret := []byte("my_secret")
+	seed := [32]byte{1, 2, 3}
+
+	shares, err := SplitFrom(rand.NewChaCha8(seed), secret, 5, 3)
+	require.NoError(t, err)
+	again, err := SplitFrom(rand.NewChaCha8(seed), secret, 5, 3)
+	require.NoError(t, err)
+	require.Equal(t, shares, again, "the same random stream should give the same shares")
+
+	restored, err := Combine(shares[2:])
+	require.NoError(t, err)
+	require.Equal(t, secret, restored)
+
+	other, err := SplitFrom(rand.NewChaCha8([32]byte{4}), secret, 5, 3)
+	require.NoError(t, err)
+	require.NotEqual(t, shares, other, "a different stream should give different shares")
+
+	_, err = SplitFrom(bytes.NewReader(make([]byte, 70)), secret, 5, 3)
+	require.ErrorIs(t, err, ErrRandomness, "an exhausted stream should fail")
+
+	shares, err = SplitWithCoordinatesFrom(rand.NewChaCha8(seed), secret, []byte{1, 2, 3}, 2)
+	require.NoError(t, err)
+	restored, err = Combine(shares[1:])
+	require.NoError(t, err)
+	require.Equal(t, secret, restored)
+}
+
+func TestIncorrectParams(t *testing.T) {
+	_, err := Split([]byte("my_secret"), 3, 1)
+	require.ErrorIs(t, err, ErrInvalidParameters, "threshold below 2 should fail")
+
+	_, err = Split([]byte("my_secret"), 2, 3)
+	require.ErrorIs(t, err, ErrInvalidParameters, "threshold above parts should fail")
+
+	_, err = Split([]byte("my_secret"), 256, 3)
+	require.ErrorIs(t, err, ErrInvalidParameters, "more than 255 parts should fail")
+
+	_, err = Split(nil, 3, 2)
+	require.ErrorIs(t, err, ErrInvalidSecret, "empty secret should fail")
+
+	_, err = Combine([][]byte{{1, 2}})
+	require.ErrorIs(t, err, ErrInsufficientShares, "single part should fail")
+
+	_, err = Combine([][]byte{{1, 2}, {1, 2, 3}})
+	require.ErrorIs(t, err, ErrInconsistentShares, "parts of different length should fail")
+
+	_, err = Combine([][]byte{{1, 2}, {3, 2}})
+	require.ErrorIs(t, err, ErrDuplicateShare, "duplicate x coordinate should fail")
+}
+
+func TestField(t *testing.T) {
+	require.Equal(t, uint8(0xc1), Mul(0x57, 0x83))
+	require.Zero(t, Inverse(0))
+	for a := 1; a < 256; a++ {
+		require.Equal(t, uint8(1), Mul(uint8(a), Inverse(uint8(a))), "a times its inverse should be one")
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// ssss, B. Poettering's ssss-split and ssss-combine, shares a secret of n
+// bytes over GF(2^8n) instead of byte by byte, so its shares cannot be
+// combined with ours. A share is "[token-]index-hex" with the index padded
+// to the width of the share count and n*2 hex digits, for example
+// "backup-2-4a8c0e11". From 64 bits on, ssss first mixes the secret with an
+// XTEA-based diffusion layer, which is undone after combining.
+//
+// Because an ssss share looks like a hex share of this tool, restore only
+// reads them with --ssss. Only the defaults of ssss are implemented: text
+// secrets with the field size set by their length and diffusion enabled.
+
+const (
+	shareFormatSSSS = "ssss"
+
+	ssssMaxDegree   = 1024
+	ssssMaxTokenLen = 128
+	// ssssMinDiffusionDegree is the smallest field ssss diffuses secrets in.
+	ssssMinDiffusionDegree = 64
+	ssssDiffusionRounds    = 40
+	xteaDelta              = 0x9e3779b9
+	// xteaDecipherSum is xteaDelta * 32, the sum after enciphering.
+	xteaDecipherSum = 0xc6ef3720
+)
+
+// ssssIrreducible lists the low terms a > b > c of the irreducible
+// pentanomial x^deg + x^a + x^b + x^c + 1 ssss reduces by, for deg = 8,
+// 16, ..., 1024.
+var ssssIrreducible = [ssssMaxDegree / 8][3]uint8{
+	{4, 3, 1}, {5, 3, 1}, {4, 3, 1}, {7, 3, 2}, {5, 4, 3}, {5, 3, 2},
+	{7, 4, 2}, {4, 3, 1}, {10, 9, 3}, {9, 4, 2}, {7, 6, 2}, {10, 9, 6},
+	{4, 3, 1}, {5, 4, 3}, {4, 3, 1}, {7, 2, 1}, {5, 3, 2}, {7, 4, 2},
+	{6, 3, 2}, {5, 3, 2}, {15, 3, 2}, {11, 3, 2}, {9, 8, 7}, {7, 2, 1},
+	{5, 3, 2}, {9, 3, 1}, {7, 3, 1}, {9, 8, 3}, {9, 4, 2}, {8, 5, 3},
+	{15, 14, 10}, {10, 5, 2}, {9, 6, 2}, {9, 3, 2}, {9, 5, 2}, {11, 10, 1},
+	{7, 3, 2}, {11, 2, 1}, {9, 7, 4}, {4, 3, 1}, {8, 3, 1}, {7, 4, 1},
+	{7, 2, 1}, {13, 11, 6}, {5, 3, 2}, {7, 3, 2}, {8, 7, 5}, {12, 3, 2},
+	{13, 10, 6}, {5, 3, 2}, {5, 3, 2}, {9, 5, 2}, {9, 7, 2}, {13, 4, 3},
+	{4, 3, 1}, {11, 6, 4}, {18, 9, 6}, {19, 18, 13}, {11, 3, 2}, {15, 9, 6},
+	{4, 3, 1}, {16, 5, 2}, {15, 14, 6}, {8, 5, 2}, {15, 11, 2}, {11, 6, 2},
+	{7, 5, 3}, {8, 3, 1}, {19, 16, 9}, {11, 9, 6}, {15, 7, 6}, {13, 4, 3},
+	{14, 13, 3}, {13, 6, 3}, {9, 5, 2}, {19, 13, 6}, {19, 10, 3}, {11, 6, 5},
+	{9, 2, 1}, {14, 3, 2}, {13, 3, 1}, {7, 5, 4}, {11, 9, 8}, {11, 6, 5},
+	{23, 16, 9}, {19, 14, 6}, {23, 10, 2}, {8, 3, 2}, {5, 4, 3}, {9, 6, 4},
+	{4, 3, 2}, {13, 8, 6}, {13, 11, 1}, {13, 10, 3}, {11, 6, 5}, {19, 17, 4},
+	{15, 14, 7}, {13, 9, 6}, {9, 7, 3}, {9, 7, 1}, {14, 3, 2}, {11, 8, 2},
+	{11, 6, 4}, {13, 5, 2}, {11, 5, 1}, {11, 4, 1}, {19, 10, 3}, {21, 10, 6},
+	{13, 3, 1}, {15, 7, 5}, {19, 18, 10}, {7, 5, 3}, {12, 7, 2}, {7, 5, 1},
+	{14, 9, 6}, {10, 3, 2}, {15, 13, 12}, {12, 11, 9}, {16, 9, 7}, {12, 9, 3},
+	{9, 5, 2}, {17, 10, 6}, {24, 9, 3}, {17, 15, 13}, {5, 4, 3}, {19, 17, 8},
+	{15, 6, 3}, {19, 6, 1},
+}
+
+// ssssElement is an element of GF(2^deg), bit i of the polynomial in bit
+// i%64 of word i/64.
+type ssssElement []uint64
+
+func (e ssssElement) add(other ssssElement) {
+	for i := range e {
+		e[i] ^= other[i]
+	}
+}
+
+// ssssField is GF(2^degree) as used by ssss.
+type ssssField struct {
+	degree int
+	// reduction is x^degree reduced, the low terms of the pentanomial.
+	reduction ssssElement
+	topMask   uint64
+}
+
+func newSSSSField(degree int) (*ssssField, error) {
+	if degree < 8 || degree > ssssMaxDegree || degree%8 != 0 {
+		return nil, sss.NewError(sss.ErrInvalidParameters, fmt.Sprintf("ssss fields have 8 to %d bits in steps of 8, not %d", ssssMaxDegree, degree), nil)
+	}
+	f := &ssssField{degree: degree, topMask: ^uint64(0)}
+	if degree%64 != 0 {
+		f.topMask = 1<<(degree%64) - 1
+	}
+	f.reduction = f.fromUint(1)
+	for _, bit := range ssssIrreducible[degree/8-1] {
+		f.reduction[bit/64] |= 1 << (bit % 64)
+	}
+	return f, nil
+}
+
+func (f *ssssField) element() ssssElement {
+	return make(ssssElement, (f.degree+63)/64)
+}
+
+func (f *ssssField) fromUint(x uint64) ssssElement {
+	e := f.element()
+	e[0] = x
+	return e
+}
+
+// fromBytes reads a big-endian number of degree/8 bytes.
+func (f *ssssField) fromBytes(data []byte) ssssElement {
+	e := f.element()
+	for i, b := range data {
+		bit := 8 * (len(data) - 1 - i)
+		e[bit/64] |= uint64(b) << (bit % 64)
+	}
+	return e
+}
+
+// putBytes writes e as a big-endian number of len(dst) bytes.
+func (f *ssssField) putBytes(dst []byte, e ssssElement) {
+	for i := range dst {
+		bit := 8 * (len(dst) - 1 - i)
+		dst[i] = byte(e[bit/64] >> (bit % 64))
+	}
+}
+
+// mul multiplies bit by bit, without branching on the bits of a or b.
+func (f *ssssField) mul(a, b ssssElement) ssssElement {
+	product := f.element()
+	shifted := slices.Clone(a)
+	defer clear(shifted)
+	top := len(shifted) - 1
+	topBit := (f.degree - 1) % 64
+	for i := 0; i < f.degree; i++ {
+		mask := -(b[i/64] >> (i % 64) & 1)
+		for w := range product {
+			product[w] ^= shifted[w] & mask
+		}
+		carry := -(shifted[top] >> topBit & 1)
+		for w := top; w > 0; w-- {
+			shifted[w] = shifted[w]<<1 | shifted[w-1]>>63
+		}
+		shifted[0] <<= 1
+		shifted[top] &= f.topMask
+		for w := range shifted {
+			shifted[w] ^= f.reduction[w] & carry
+		}
+	}
+	return product
+}
+
+// inverse raises a to 2^degree - 2.
+func (f *ssssField) inverse(a ssssElement) ssssElement {
+	result := f.fromUint(1)
+	power := a
+	for i := 1; i < f.degree; i++ {
+		power = f.mul(power, power)
+		result = f.mul(result, power)
+	}
+	return result
+}
+
+// pow raises a to a small exponent.
+func (f *ssssField) pow(a ssssElement, exponent int) ssssElement {
+	result := f.fromUint(1)
+	for range exponent {
+		result = f.mul(result, a)
+	}
+	return result
+}
+
+// lagrangeWeights returns 1 / prod(xs[i] + xs[j], j != i) for each i. An
+// inversion is slow in large fields, so all of them share one.
+func (f *ssssField) lagrangeWeights(xs []ssssElement) []ssssElement {
+	denominators := make([]ssssElement, len(xs))
+	// prefix[i] is the product of the first i denominators.
+	prefix := make([]ssssElement, len(xs)+1)
+	prefix[0] = f.fromUint(1)
+	for i := range xs {
+		denominators[i] = f.fromUint(1)
+		for j := range xs {
+			if i != j {
+				term := slices.Clone(xs[i])
+				term.add(xs[j])
+				denominators[i] = f.mul(denominators[i], term)
+			}
+		}
+		prefix[i+1] = f.mul(prefix[i], denominators[i])
+	}
+	weights := make([]ssssElement, len(xs))
+	inverse := f.inverse(prefix[len(xs)])
+	for i := len(xs) - 1; i >= 0; i-- {
+		weights[i] = f.mul(inverse, prefix[i])
+		inverse = f.mul(inverse, denominators[i])
+	}
+	return weights
+}
+
+// interpolate evaluates at z the polynomial of lowest degree through the
+// points (xs[i], ys[i]), given the lagrangeWeights of xs.
+func (f *ssssField) interpolate(xs, ys, weights []ssssElement, z ssssElement) ssssElement {
+	result := f.element()
+	for i := range xs {
+		term := f.mul(ys[i], weights[i])
+		for j := range xs {
+			if i != j {
+				factor := slices.Clone(z)
+				factor.add(xs[j])
+				term = f.mul(term, factor)
+			}
+		}
+		result.add(term)
+		clear(term)
+	}
+	return result
+}
+
+// ssssDiffuse applies the diffusion layer of ssss to a big-endian secret in
+// place, or removes it with decode. ssss exports the number as 16-bit
+// big-endian words, least significant first; with an odd number of bytes
+// the top byte is moved down into the padding byte before it. Each step
+// then enciphers 8 bytes of that buffer, wrapping around, with 32 rounds of
+// XTEA under an all-zero key.
+func ssssDiffuse(secret []byte, decode bool) {
+	n := len(secret)
+	buf := make([]byte, (n+1)/2*2)
+	defer clear(buf)
+	for i := range n {
+		// Byte i counted from the least significant end.
+		buf[i^1] = secret[n-1-i]
+	}
+	if n%2 == 1 {
+		buf[n-1] = buf[n]
+	}
+	if decode {
+		for i := ssssDiffusionRounds*n - 2; i >= 0; i -= 2 {
+			ssssDiffuseSlice(buf[:n], i, xteaDecipher)
+		}
+	} else {
+		for i := 0; i < ssssDiffusionRounds*n; i += 2 {
+			ssssDiffuseSlice(buf[:n], i, xteaEncipher)
+		}
+	}
+	if n%2 == 1 {
+		buf[n] = buf[n-1]
+		buf[n-1] = 0
+	}
+	for i := range n {
+		secret[n-1-i] = buf[i^1]
+	}
+}
+
+// ssssDiffuseSlice runs process on the 8 bytes of data from idx on,
+// wrapping around its end.
+func ssssDiffuseSlice(data []byte, idx int, process func(v *[2]uint32)) {
+	var block [8]byte
+	for k := range block {
+		block[k] = data[(idx+k)%len(data)]
+	}
+	v := [2]uint32{binary.BigEndian.Uint32(block[:4]), binary.BigEndian.Uint32(block[4:])}
+	process(&v)
+	binary.BigEndian.PutUint32(block[:4], v[0])
+	binary.BigEndian.PutUint32(block[4:], v[1])
+	for k := range block {
+		data[(idx+k)%len(data)] = block[k]
+	}
+	clear(block[:])
+}
+
+func xteaEncipher(v *[2]uint32) {
+	var sum uint32
+	for range 32 {
+		v[0] += (v[1]<<4 ^ v[1]>>5) + v[1] ^ sum
+		sum += xteaDelta
+		v[1] += (v[0]<<4 ^ v[0]>>5) + v[0] ^ sum
+	}
+}
+
+func xteaDecipher(v *[2]uint32) {
+	sum := uint32(xteaDecipherSum)
+	for range 32 {
+		v[1] -= (v[0]<<4 ^ v[0]>>5) + v[0] ^ sum
+		sum -= xteaDelta
+		v[0] -= (v[1]<<4 ^ v[1]>>5) + v[1] ^ sum
+	}
+}
+
+// checkSSSSToken rejects tokens ssss-combine would not read back.
+func checkSSSSToken(token string) error {
+	if len(token) > ssssMaxTokenLen || strings.ContainsAny(token, "-, \t\r\n") {
+		return sss.NewError(errUsage, fmt.Sprintf("--token must be at most %d characters without hyphens, commas or spaces", ssssMaxTokenLen), nil)
+	}
+	return nil
+}
+
+// ssssFormat is the shareFormat of ssss shares.
+type ssssFormat struct {
+	token string
+}
+
+func (*ssssFormat) name() string { return shareFormatSSSS }
+
+func (f *ssssFormat) setFlags(flags *flag.FlagSet) {
+	flags.StringVar(&f.token, "token", "", "name to prefix ssss shares with, like ssss-split -w")
+}
+
+func (*ssssFormat) check(opts splitOptions) error {
+	switch {
+	case opts.pad != "" || opts.compact || opts.secretType == secretTypeBase64:
+		return sss.NewError(errUsage, "--pad, --compact and --type base64 cannot be combined with --format ssss, ssss shares carry text without a share mode", nil)
+	case opts.generatedBytes:
+		// ssss-combine drops leading zero bytes of a binary secret.
+		return sss.NewError(errUsage, "--generate bytes cannot be combined with --format ssss", nil)
+	case opts.print || opts.qr:
+		// Sheets and QR codes tell holders to run a plain restore, which
+		// would read ssss shares as hex shares of this tool.
+		return sss.NewError(errUsage, "--print and --qr cannot be combined with --format ssss", nil)
+	}
+	return nil
+}
+
+func (f *ssssFormat) split(random io.Reader, secret []byte, opts splitOptions) (splitShares, error) {
+	encoded, err := splitSSSS(random, secret, f.token, opts.totalShares, opts.threshold)
+	return splitShares{encoded: encoded, mode: shareModeRaw}, err
+}
+
+// write prints one share per line, as ssss-split does.
+func (*ssssFormat) write(w io.Writer, shares splitShares, opts splitOptions) error {
+	_, err := fmt.Fprintln(w, strings.ReplaceAll(shares.encoded, ",", "\n"))
+	return err
+}
+
+func (*ssssFormat) describe(encodedShare string) (jsonShare, []byte, error) {
+	return describeSSSSShare(encodedShare)
+}
+
+// splitSSSS splits a secret as `ssss-split -t threshold -n totalShares`
+// does, with an optional -w token, and returns the shares separated by
+// commas.
+func splitSSSS(random io.Reader, secret []byte, token string, totalShares int, threshold int) (string, error) {
+	switch {
+	case totalShares < threshold:
+		return "", sss.NewError(sss.ErrInvalidParameters, "parts cannot be less than threshold", nil)
+	case totalShares > 255:
+		return "", sss.NewError(sss.ErrInvalidParameters, "parts cannot exceed 255", nil)
+	case threshold < 2:
+		return "", sss.NewError(sss.ErrInvalidParameters, "threshold must be at least 2", nil)
+	case len(secret) == 0:
+		return "", sss.NewError(sss.ErrInvalidSecret, "cannot split an empty secret", nil)
+	case len(secret) > ssssMaxDegree/8:
+		return "", sss.NewError(sss.ErrInvalidSecret, fmt.Sprintf("ssss secrets are at most %d bytes", ssssMaxDegree/8), nil)
+	}
+	if err := checkSSSSToken(token); err != nil {
+		return "", err
+	}
+	f, err := newSSSSField(8 * len(secret))
+	if err != nil {
+		return "", err
+	}
+
+	// The polynomial is x^threshold + c[threshold-1] x^(threshold-1) + ...
+	// + c[0], with the secret in c[0], as evaluated by ssss.
+	coeffs := make([]ssssElement, threshold)
+	defer func() {
+		for _, c := range coeffs {
+			clear(c)
+		}
+	}()
+	buf := make([]byte, len(secret))
+	defer clear(buf)
+	copy(buf, secret)
+	if f.degree >= ssssMinDiffusionDegree {
+		ssssDiffuse(buf, false)
+	}
+	coeffs[0] = f.fromBytes(buf)
+	for i := 1; i < threshold; i++ {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return "", sss.NewError(sss.ErrRandomness, "failed to generate polynomial", err)
+		}
+		coeffs[i] = f.fromBytes(buf)
+	}
+
+	width := len(strconv.Itoa(totalShares))
+	shares := make([]string, totalShares)
+	for i := range shares {
+		x := f.fromUint(uint64(i + 1))
+		y := slices.Clone(x)
+		for j := threshold - 1; j > 0; j-- {
+			y.add(coeffs[j])
+			y = f.mul(y, x)
+		}
+		y.add(coeffs[0])
+		f.putBytes(buf, y)
+		clear(y)
+		shares[i] = fmt.Sprintf("%0*d-%s", width, i+1, hex.EncodeToString(buf))
+		if token != "" {
+			shares[i] = token + "-" + shares[i]
+		}
+	}
+	return strings.Join(shares, ","), nil
+}
+
+// ssssShare is a parsed ssss share. The caller must clear y.
+type ssssShare struct {
+	token string
+	index int
+	y     []byte
+}
+
+// parseSSSSShare reads "[token-]index-hex".
+func parseSSSSShare(text string) (ssssShare, error) {
+	var share ssssShare
+	parts := strings.Split(strings.TrimSpace(text), "-")
+	switch len(parts) {
+	case 2:
+	case 3:
+		share.token = parts[0]
+		parts = parts[1:]
+	default:
+		return ssssShare{}, sss.NewError(sss.ErrMalformedShare, "ssss shares look like [token-]index-hex", nil)
+	}
+	index, err := strconv.Atoi(parts[0])
+	if err != nil || index < 1 {
+		return ssssShare{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("invalid ssss share index %q", parts[0]), nil)
+	}
+	share.index = index
+	share.y, err = hex.DecodeString(parts[1])
+	if err != nil {
+		return ssssShare{}, sss.NewError(sss.ErrMalformedShare, "invalid ssss share hex", err)
+	}
+	if len(share.y) == 0 || len(share.y) > ssssMaxDegree/8 || index >= 1<<min(8*len(share.y), 31) {
+		clear(share.y)
+		return ssssShare{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("ssss share %d does not fit its field", index), nil)
+	}
+	return share, nil
+}
+
+// describeSSSSShare is describeShare for an ssss share.
+func describeSSSSShare(encodedShare string) (jsonShare, []byte, error) {
+	share, err := parseSSSSShare(encodedShare)
+	if err != nil {
+		return jsonShare{}, nil, err
+	}
+	return jsonShare{
+		Index:       share.index,
+		Label:       fmt.Sprintf("Share %d", share.index),
+		Encoding:    shareFormatSSSS,
+		Mode:        shareModeName(shareModeRaw),
+		Payload:     encodedShare,
+		Fingerprint: shareFingerprint(share.y),
+	}, share.y, nil
+}
+
+// combineSSSS recovers the secret from ssss shares into a new secure buffer
+// as `ssss-combine -t threshold` does. ssss shares do not record their
+// threshold and any number of them combine to some secret, so the threshold
+// must be given. With a token, every share must carry it. Shares beyond the
+// threshold must agree with the others. Like ssss-combine, the secret is printed without
+// leading zero bytes.
+func combineSSSS(encodedShares string, token string, threshold int) (*secureBuffer, error) {
+	shareStrings := splitShareList(encodedShares)
+	if threshold < 2 {
+		return nil, sss.NewError(sss.ErrInvalidParameters, "threshold must be at least 2", nil)
+	}
+	if len(shareStrings) < threshold {
+		return nil, sss.NewError(sss.ErrInsufficientShares, fmt.Sprintf("the shares need %d shares, got %d", threshold, len(shareStrings)), nil)
+	}
+
+	var shares []ssssShare
+	defer func() {
+		for _, share := range shares {
+			clear(share.y)
+		}
+	}()
+	seen := make(map[int]bool)
+	for i, shareString := range shareStrings {
+		share, err := parseSSSSShare(shareString)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+		switch {
+		case token != "" && share.token != token:
+			return nil, sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("ssss share %d does not carry the token %q", share.index, token), nil)
+		case i > 0 && share.token != shares[0].token:
+			return nil, sss.NewError(sss.ErrInconsistentShares, "ssss shares carry different tokens", nil)
+		case len(share.y) != len(shares[0].y):
+			return nil, sss.NewError(sss.ErrInconsistentShares, "all parts must be the same length", nil)
+		case seen[share.index]:
+			return nil, sss.NewError(sss.ErrDuplicateShare, fmt.Sprintf("ssss share %d is given twice", share.index), nil)
+		}
+		seen[share.index] = true
+	}
+	f, err := newSSSSField(8 * len(shares[0].y))
+	if err != nil {
+		return nil, err
+	}
+
+	// Taking x^threshold off each y leaves points of a polynomial of degree
+	// threshold-1 with the secret as its constant.
+	xs := make([]ssssElement, len(shares))
+	ys := make([]ssssElement, len(shares))
+	defer func() {
+		for _, y := range ys {
+			clear(y)
+		}
+	}()
+	for i, share := range shares {
+		xs[i] = f.fromUint(uint64(share.index))
+		ys[i] = f.fromBytes(share.y)
+		ys[i].add(f.pow(xs[i], threshold))
+	}
+	weights := f.lagrangeWeights(xs[:threshold])
+	for k := threshold; k < len(shares); k++ {
+		expected := f.interpolate(xs[:threshold], ys[:threshold], weights, xs[k])
+		agree := slices.Equal(expected, ys[k])
+		clear(expected)
+		if !agree {
+			return nil, sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("ssss share %d does not agree with the others, or the threshold is wrong", shares[k].index), nil)
+		}
+	}
+
+	constant := f.interpolate(xs[:threshold], ys[:threshold], weights, f.element())
+	defer clear(constant)
+	buf, err := newSecureBuffer(f.degree / 8)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Destroy()
+	f.putBytes(buf.Bytes(), constant)
+	if f.degree >= ssssMinDiffusionDegree {
+		ssssDiffuse(buf.Bytes(), true)
+	}
+	secret := buf.Bytes()
+	for len(secret) > 0 && secret[0] == 0 {
+		secret = secret[1:]
+	}
+	return newSecureBufferFrom(secret)
+}
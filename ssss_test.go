@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSSSField(t *testing.T) {
+	// GF(2^8) reduces by the AES polynomial.
+	f, err := newSSSSField(8)
+	require.NoError(t, err)
+	require.Equal(t, ssssElement{0xc1}, f.mul(ssssElement{0x57}, ssssElement{0x83}))
+
+	for _, degree := range []int{8, 64, 72, 136, 1024} {
+		f, err := newSSSSField(degree)
+		require.NoError(t, err)
+		secret := bytes.Repeat([]byte{0xa5, 0x3c, 0x00, 0xff}, degree/32+1)[:degree/8]
+		a := f.fromBytes(secret)
+		require.Equal(t, f.fromUint(1), f.mul(a, f.inverse(a)), degree)
+
+		back := make([]byte, degree/8)
+		f.putBytes(back, a)
+		require.Equal(t, secret, back)
+	}
+
+	for _, degree := range []int{0, 12, 1032} {
+		_, err := newSSSSField(degree)
+		require.Error(t, err)
+		require.Equal(t, exitInvalidParameters, exitCode(err))
+	}
+}
+
+func TestSSSSDiffuse(t *testing.T) {
+	for _, size := range []int{8, 9, 16, 33, 128} {
+		secret := bytes.Repeat([]byte("abcdefg"), 20)[:size]
+		diffused := bytes.Clone(secret)
+		ssssDiffuse(diffused, false)
+		require.NotEqual(t, secret, diffused, size)
+		ssssDiffuse(diffused, true)
+		require.Equal(t, secret, diffused, size)
+	}
+}
+
+func TestCombineSSSS(t *testing.T) {
+	// y = x^2 + 0x53 x + 'A' over GF(2^8), worked out by hand.
+	secret, err := combineSSSS("1-13,2-e3", "", 2)
+	require.NoError(t, err)
+	require.Equal(t, "A", string(secret.Bytes()))
+	secret.Destroy()
+
+	encoded, err := splitSSSS(deterministicReader("ssss"), []byte("correct horse battery staple"), "backup", 12, 3)
+	require.NoError(t, err)
+	shares := strings.Split(encoded, ",")
+	require.Len(t, shares, 12)
+	require.Regexp(t, regexp.MustCompile(`^backup-07-[0-9a-f]{56}$`), shares[6])
+
+	tests := []struct {
+		name      string
+		shares    []string
+		token     string
+		threshold int
+		code      int
+	}{
+		{"threshold shares", []string{shares[1], shares[4], shares[8]}, "", 3, exitOK},
+		{"with token", []string{shares[11], shares[0], shares[5]}, "backup", 3, exitOK},
+		{"extra shares agree", []string{shares[1], shares[4], shares[8], shares[2]}, "", 3, exitOK},
+		{"wrong token", []string{shares[1], shares[4], shares[8]}, "other", 3, exitInconsistentShares},
+		{"mixed tokens", []string{shares[1], strings.TrimPrefix(shares[4], "backup-"), shares[8]}, "", 3, exitInconsistentShares},
+		{"duplicate share", []string{shares[1], shares[4], shares[1]}, "", 3, exitMalformedShare},
+		{"too few shares", []string{shares[1], shares[4]}, "", 3, exitInsufficientShares},
+		{"extra share disagrees", []string{shares[1], shares[4], shares[8], "backup-03-" + strings.Repeat("00", 28)}, "", 3, exitInconsistentShares},
+		{"wrong threshold caught by extra share", []string{shares[1], shares[4], shares[8]}, "", 2, exitInconsistentShares},
+		{"different lengths", []string{shares[1], "backup-05-0a0b"}, "", 2, exitInconsistentShares},
+		{"not ssss", []string{shares[1], "backup-x-0a0b"}, "", 2, exitMalformedShare},
+		{"threshold of one", []string{shares[1], shares[4]}, "", 1, exitInvalidParameters},
+		{"no threshold", []string{shares[1], shares[4], shares[8]}, "", 0, exitInvalidParameters},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := combineSSSS(strings.Join(tt.shares, ","), tt.token, tt.threshold)
+			if tt.code != exitOK {
+				require.Error(t, err)
+				require.Equal(t, tt.code, exitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			defer secret.Destroy()
+			require.Equal(t, "correct horse battery staple", string(secret.Bytes()))
+		})
+	}
+
+	_, err = splitSSSS(deterministicReader("ssss"), bytes.Repeat([]byte("x"), 129), "", 3, 2)
+	require.Equal(t, exitInvalidSecret, exitCode(err))
+	_, err = splitSSSS(deterministicReader("ssss"), []byte("secret"), "back-up", 3, 2)
+	require.Equal(t, exitUsage, exitCode(err))
+}
+
+func TestSSSSVectors(t *testing.T) {
+	// The 3-of-5 example on the ssss homepage, written by ssss-split with
+	// the 184-bit security level it picks for this 23-byte secret.
+	shares := []string{
+		"1-1c41ef496eccfbeba439714085df8437236298da8dd824",
+		"2-fbc74a03a50e14ab406c225afb5f45c40ae11976d2b665",
+		"3-fa1c3a9c6df8af0779c36de6c33f6e36e989d0e0b91309",
+		"4-468de7d6eb36674c9cf008c8e8fc8c566537ad6301eb9e",
+		"5-4756974923c0dce0a55f4774d09ca7a4865f64f56a4ee0",
+	}
+	// ssss-split -w only prefixes each share with the token, it does not
+	// enter the shared polynomial.
+	tokenShares := make([]string, len(shares))
+	for i, share := range shares {
+		tokenShares[i] = "root-" + share
+	}
+
+	tests := []struct {
+		name      string
+		shares    []string
+		token     string
+		threshold int
+		code      int
+	}{
+		{"threshold shares", []string{shares[2], shares[4], shares[1]}, "", 3, exitOK},
+		{"all shares", shares, "", 3, exitOK},
+		{"with token", []string{tokenShares[0], tokenShares[3], tokenShares[4]}, "root", 3, exitOK},
+		{"token required", []string{tokenShares[0], tokenShares[3], tokenShares[4]}, "backup", 3, exitInconsistentShares},
+		{"threshold too high", shares[:3], "", 4, exitInsufficientShares},
+		{"threshold too low", shares[:3], "", 2, exitInconsistentShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := combineSSSS(strings.Join(tt.shares, ","), tt.token, tt.threshold)
+			if tt.code != exitOK {
+				require.Error(t, err)
+				require.Equal(t, tt.code, exitCode(err))
+				return
+			}
+			require.NoError(t, err)
+			defer secret.Destroy()
+			require.Equal(t, "my secret root password", string(secret.Bytes()))
+		})
+	}
+
+	// Secrets of other lengths round-trip through a split and a restore.
+	for _, size := range []int{1, 8, 9, 17, 128} {
+		want := strings.Repeat("Secret!", 20)[:size]
+		encoded, err := splitSSSS(deterministicReader("vectors"), []byte(want), "w", 4, 3)
+		require.NoError(t, err)
+		shares := strings.Split(encoded, ",")
+		secret, err := combineSSSS(strings.Join([]string{shares[3], shares[0], shares[2]}, ","), "w", 3)
+		require.NoError(t, err, size)
+		require.Equal(t, want, string(secret.Bytes()), size)
+		secret.Destroy()
+	}
+}
+
+func TestSplitAndRestoreSSSS(t *testing.T) {
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "ssss", "--token", "vault", "my_secret", "2", "3"})
+	})
+	require.Equal(t, exitOK, code)
+	shares := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, shares, 3)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--ssss", "--token", "vault", "--threshold", "2", shares[2] + "\n" + shares[0]})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--ssss", "--threshold", "2", "--shares", strings.Join(shares, ",")})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "my_secret\n", out)
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--token", "vault", "my_secret", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "ssss", "--generate", "bytes:16", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "ssss", "--print", t.TempDir(), "hello there", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "ssss", "--qr", "terminal", "hello there", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "ssss", "--token", "vault", "--print", t.TempDir(), "hello there", "2", "3"}))
+	require.Equal(t, exitUsage, runCLI([]string{"restore", "--token", "vault", shares[0] + "," + shares[1]}))
+	require.Equal(t, exitUsage, runCLI([]string{"restore", "--ssss", "--threshold", "2", "--interactive"}))
+
+	// ssss shares do not record their threshold, and more shares than it
+	// would combine to a wrong secret, so it must be given.
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "ssss", "hello world secret", "3", "5"})
+	})
+	require.Equal(t, exitOK, code)
+	shares = strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Equal(t, exitUsage, runCLI([]string{"restore", "--ssss", "--shares", strings.Join(shares[:4], ",")}))
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--ssss", "--threshold", "3", "--shares", strings.Join(shares[:4], ",")})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, "hello world secret\n", out)
+}
@@ -26,7 +26,7 @@ run_test() {
     # echo "Python Encoded: $python_encoded"
 
     # Go decode Python encoded
-    go_decoded=$(go run shamir.go restore "$python_encoded")
+    go_decoded=$(go run . restore "$python_encoded")
     # echo "Go Decoded: $go_decoded"
 
     # Check if the Go decoded matches the original secret
@@ -36,7 +36,7 @@ run_test() {
     fi
 
     # Go encode
-    go_encoded=$(go run shamir.go split "$secret" "$threshold" "$total_shares")
+    go_encoded=$(go run . split "$secret" "$threshold" "$total_shares")
     # echo "Go Encoded: $go_encoded"
 
     # Python decode Go encoded
@@ -65,14 +65,14 @@ run_test() {
         # Decode with n shares
         if [ "$n" -lt "$threshold" ]; then
             # Expect an error
-            go_decoded=$(go run shamir.go restore "$shares_input" 2>&1)
+            go_decoded=$(go run . restore "$shares_input" 2>&1)
             if [ "$go_decoded" = "$secret" ]; then
                 echo -ne "\rTesting with $n/$total_shares ordered shares with $threshold threshold... NOK! --> Expected error when decoding with Go with $n shares, but got success"
                 exit 1
             fi
         else
             # Expect success
-            go_decoded=$(go run shamir.go restore "$shares_input")
+            go_decoded=$(go run . restore "$shares_input")
             if [ "$go_decoded" != "$secret" ]; then
                 echo -ne "\rTesting with $n/$total_shares ordered shares with $threshold threshold... NOK! --> Go failed to decode Python encoded secret with $n shares"
                 exit 1
@@ -107,14 +107,14 @@ run_test() {
         # Decode with n shares
         if [ "$n" -lt "$threshold" ]; then
             # Expect an error
-            go_decoded=$(go run shamir.go restore "$shares_input" 2>&1)
+            go_decoded=$(go run . restore "$shares_input" 2>&1)
             if [ "$go_decoded" = "$secret" ]; then
                 echo -ne "\rTesting with $n/$total_shares shuffled shares with $threshold threshold... NOK! --> Expected error when decoding with Go with $n shares, but got success"
                 exit 1
             fi
         else
             # Expect success
-            go_decoded=$(go run shamir.go restore "$shares_input")
+            go_decoded=$(go run . restore "$shares_input")
             if [ "$go_decoded" != "$secret" ]; then
                 echo -ne "\rTesting with $n/$total_shares shuffled shares with $threshold threshold... NOK! --> Go failed to decode Python encoded secret with $n shares"
                 exit 1
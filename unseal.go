@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// vault-unseal hands shares to a sealed Vault server one at a time through
+// its HTTP API, like `vault operator unseal` does. Each share is decoded into
+// a Vault key and submitted as soon as it is entered, before the next one is
+// asked for. Vault combines the keys itself; this tool never holds more than
+// one at a time.
+
+const vaultRequestTimeout = 30 * time.Second
+
+// vaultSealStatus is the answer of /v1/sys/seal-status and /v1/sys/unseal.
+type vaultSealStatus struct {
+	Sealed    bool `json:"sealed"`
+	Threshold int  `json:"t"`
+	Shares    int  `json:"n"`
+	Progress  int  `json:"progress"`
+}
+
+type unsealResult struct {
+	jsonHeader
+	Address   string `json:"address"`
+	Sealed    bool   `json:"sealed"`
+	Threshold int    `json:"threshold"`
+	Progress  int    `json:"progress"`
+	Submitted int    `json:"submitted"`
+}
+
+// vaultClient calls the unauthenticated seal endpoints of a Vault server.
+type vaultClient struct {
+	addr   string
+	client *http.Client
+}
+
+func newVaultClient(addr string) *vaultClient {
+	return &vaultClient{addr: strings.TrimRight(addr, "/"), client: &http.Client{Timeout: vaultRequestTimeout}}
+}
+
+func (c *vaultClient) sealStatus() (vaultSealStatus, error) {
+	return c.call(http.MethodGet, "/v1/sys/seal-status", nil)
+}
+
+// submitKey sends one key to /v1/sys/unseal and returns the new status.
+func (c *vaultClient) submitKey(key string) (vaultSealStatus, error) {
+	body, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return vaultSealStatus{}, err
+	}
+	defer clear(body)
+	return c.call(http.MethodPut, "/v1/sys/unseal", body)
+}
+
+func (c *vaultClient) call(method, path string, body []byte) (vaultSealStatus, error) {
+	req, err := http.NewRequest(method, c.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return vaultSealStatus{}, sss.NewError(errUsage, "invalid --addr", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return vaultSealStatus{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return vaultSealStatus{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &failure) == nil && len(failure.Errors) > 0 {
+			return vaultSealStatus{}, fmt.Errorf("vault answered %s: %s", resp.Status, strings.Join(failure.Errors, "; "))
+		}
+		return vaultSealStatus{}, fmt.Errorf("vault answered %s", resp.Status)
+	}
+	var status vaultSealStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return vaultSealStatus{}, fmt.Errorf("invalid answer from vault: %w", err)
+	}
+	return status, nil
+}
+
+// vaultUnsealKey turns a share into the base64 key Vault expects. Only raw
+// shares are Vault keys; a share with a mode was split from something else.
+func vaultUnsealKey(encodedShare string) (string, error) {
+	if isCodex32Shares(encodedShare) {
+		return "", sss.NewError(sss.ErrInconsistentShares, "codex32 shares cannot unseal Vault", nil)
+	}
+	_, share, err := decodeShareRecord(encodedShare)
+	if err != nil {
+		return "", err
+	}
+	defer clear(share.Data)
+	if share.Mode != shareModeRaw {
+		return "", sss.NewError(sss.ErrInconsistentShares, fmt.Sprintf("share %d has mode %s, only raw shares are Vault keys", share.Index, share.Mode), nil)
+	}
+	return base64.StdEncoding.EncodeToString(share.Data), nil
+}
+
+func vaultUnsealCommand(flags *flag.FlagSet) func(args []string) int {
+	addr := flags.String("addr", os.Getenv("VAULT_ADDR"), "address of the Vault server, $VAULT_ADDR by default")
+	output := flags.String("output", outputText, "output format: text or json")
+
+	return func(args []string) int {
+		out, err := newReporter("vault-unseal", *output)
+		if err != nil {
+			return fail("Invalid arguments", err)
+		}
+		if err := bindPositional(flags, 0); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+		if *addr == "" {
+			return out.failWith(errUsage, "--addr is required when VAULT_ADDR is not set")
+		}
+
+		client := newVaultClient(*addr)
+		status, err := client.sealStatus()
+		if err != nil {
+			return out.fail("Error reaching Vault", err)
+		}
+		result := unsealResult{jsonHeader: out.header(), Address: *addr}
+		if status.Sealed {
+			fmt.Fprintf(os.Stderr, "Vault at %s is sealed, %d of %d keys entered.\n", *addr, status.Progress, status.Threshold)
+			// Each share is decoded and submitted before the next one is
+			// asked for, so only one is held at a time.
+			r := newLineReader(os.Stdin, os.Stderr)
+			fmt.Fprintln(os.Stderr, shareEntryHelp)
+			for number := 1; status.Sealed; {
+				share, err := r.readShare(number)
+				if err != nil {
+					return out.fail("Error reading shares", err)
+				}
+				if share == "" {
+					break
+				}
+				key, err := vaultUnsealKey(share)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Share %d rejected: %v. Enter the whole share again.\n", number, err)
+					continue
+				}
+				if status, err = client.submitKey(key); err != nil {
+					return out.fail(fmt.Sprintf("Error submitting share %d", number), err)
+				}
+				result.Submitted++
+				if status.Sealed {
+					fmt.Fprintf(os.Stderr, "Share %d accepted, %d of %d keys entered.\n", number, status.Progress, status.Threshold)
+				}
+				number++
+			}
+		}
+		result.Sealed, result.Threshold, result.Progress = status.Sealed, status.Threshold, status.Progress
+		if status.Sealed {
+			return out.failWith(sss.ErrInsufficientShares, fmt.Sprintf("Vault is still sealed, %d of %d keys entered", status.Progress, status.Threshold))
+		}
+		if out.json() {
+			out.emit(result)
+		} else {
+			fmt.Println("Vault is unsealed")
+		}
+		return exitOK
+	}
+}
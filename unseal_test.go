@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/shamir"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault stands in for the seal endpoints of a Vault server. Like Vault,
+// it combines the keys once it has enough of them and starts over when they
+// do not give its root key.
+type fakeVault struct {
+	mu        sync.Mutex
+	rootKey   []byte
+	threshold int
+	sealed    bool
+	keys      [][]byte
+	received  []string
+}
+
+func (v *fakeVault) status() vaultSealStatus {
+	return vaultSealStatus{Sealed: v.sealed, Threshold: v.threshold, Shares: 5, Progress: len(v.keys)}
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/sys/seal-status":
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/sys/unseal":
+		var req struct {
+			Key string `json:"key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"errors": ["invalid request"]}`, http.StatusBadRequest)
+			return
+		}
+		v.received = append(v.received, req.Key)
+		key, err := base64.StdEncoding.DecodeString(req.Key)
+		if err != nil {
+			http.Error(w, `{"errors": ["invalid key"]}`, http.StatusBadRequest)
+			return
+		}
+		v.keys = append(v.keys, key)
+		if len(v.keys) == v.threshold {
+			combined, err := shamir.Combine(v.keys)
+			v.keys = nil
+			if err != nil || !bytes.Equal(combined, v.rootKey) {
+				http.Error(w, `{"errors": ["Error unsealing: cipher: message authentication failed"]}`, http.StatusBadRequest)
+				return
+			}
+			v.sealed = false
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(v.status())
+}
+
+// withStdin runs f with os.Stdin reading text.
+func withStdin(t *testing.T, text string, f func()) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o600))
+	stdin, err := os.Open(path)
+	require.NoError(t, err)
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+	f()
+}
+
+func TestVaultUnseal(t *testing.T) {
+	rootKey := bytes.Repeat([]byte{0x42}, 32)
+	shares, err := shamir.Split(rootKey, 5, 3)
+	require.NoError(t, err)
+	keys := make([]string, len(shares))
+	for i, share := range shares {
+		keys[i] = base64.StdEncoding.EncodeToString(share)
+	}
+	// The same key written as a hex share of this tool.
+	hexShare := encodeShare(2, shareModeRaw, shares[1])
+	otherShares, _, err := splitPrepared(deterministicReader("other"), rootKey, secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		sealed   bool
+		input    string
+		code     int
+		received []string
+	}{
+		{"unseals", true, keys[0] + "\n" + hexShare + "\n" + keys[4] + "\n\n", exitOK, []string{keys[0], keys[1], keys[4]}},
+		{"stops once unsealed", true, keys[0] + "\n" + keys[2] + "\n" + keys[3] + "\n" + keys[4] + "\n", exitOK, []string{keys[0], keys[2], keys[3]}},
+		{"too few keys", true, keys[0] + "\n" + keys[1] + "\n\n", exitInsufficientShares, []string{keys[0], keys[1]}},
+		{"already unsealed", false, "", exitOK, nil},
+		{"share with a mode asked again", true, "1-bip39-6bfe01\n" + strings.Join(keys[:3], "\n") + "\n", exitOK, keys[:3]},
+		{"malformed share asked again", true, keys[0] + "\n1-zz\n" + keys[1] + "\n" + keys[2] + "\n", exitOK, keys[:3]},
+		{"only a share with a mode", true, "1-bip39-6bfe01\n\n", exitInsufficientShares, nil},
+		{"rejected by vault", true, keys[0] + "\n" + keys[1] + "\n" + strings.Split(otherShares, ",")[0] + "\n\n", exitError, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := &fakeVault{rootKey: rootKey, threshold: 3, sealed: tt.sealed}
+			server := httptest.NewServer(vault)
+			defer server.Close()
+
+			var code int
+			var out string
+			withStdin(t, tt.input, func() {
+				out = captureStdout(t, func() {
+					code = runCLI([]string{"vault-unseal", "--addr", server.URL + "/"})
+				})
+			})
+			require.Equal(t, tt.code, code)
+			if tt.received != nil {
+				require.Equal(t, tt.received, vault.received)
+			}
+			require.Equal(t, tt.code == exitOK, !vault.sealed)
+			if code == exitOK {
+				require.Equal(t, "Vault is unsealed\n", out)
+			}
+		})
+	}
+
+	vault := &fakeVault{rootKey: rootKey, threshold: 3, sealed: true}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+	var out string
+	withStdin(t, strings.Join(keys[:3], "\n")+"\n", func() {
+		out = captureStdout(t, func() {
+			require.Equal(t, exitOK, runCLI([]string{"vault-unseal", "--output", "json", "--addr", server.URL}))
+		})
+	})
+	var result unsealResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.True(t, result.OK)
+	require.False(t, result.Sealed)
+	require.Equal(t, 3, result.Submitted)
+
+	// Each share reaches Vault before the next one is read.
+	vault = &fakeVault{rootKey: rootKey, threshold: 3, sealed: true}
+	server = httptest.NewServer(vault)
+	defer server.Close()
+	stdin, input, err := os.Pipe()
+	require.NoError(t, err)
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+	done := make(chan int)
+	go func() { done <- runCLI([]string{"vault-unseal", "--addr", server.URL}) }()
+	for i, key := range keys[:3] {
+		_, err := input.WriteString(key + "\n")
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			vault.mu.Lock()
+			defer vault.mu.Unlock()
+			return len(vault.received) == i+1
+		}, 5*time.Second, 10*time.Millisecond, "share %d should be submitted before more input arrives", i+1)
+	}
+	require.Equal(t, exitOK, <-done)
+	input.Close()
+	stdin.Close()
+
+	t.Setenv("VAULT_ADDR", "")
+	require.Equal(t, exitUsage, runCLI([]string{"vault-unseal"}))
+	require.Equal(t, exitError, runCLI([]string{"vault-unseal", "--addr", server.URL + "/missing"}))
+}
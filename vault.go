@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// HashiCorp Vault splits its root key with the same field and share layout
+// as this tool, so its unseal and recovery keys are raw shares without an
+// index. `vault operator init -format=json` prints them as:
+//
+//	{
+//	  "unseal_keys_b64": ["mH0L...", ...],
+//	  "unseal_keys_hex": ["987d0b...", ...],
+//	  "unseal_shares": 5,
+//	  "unseal_threshold": 3,
+//	  ...
+//	}
+//
+// A single key in base64, as given to `vault operator unseal`, is read by
+// vaultEncoding; a whole init document by readShareFiles. A single key in hex
+// is not recognized, as it would be taken for a hex share without its index.
+
+const (
+	shareFormatVault = "vault"
+
+	// secretTypeBase64 is the --type of binary secrets such as a Vault root
+	// key, given to split and printed by restore in base64.
+	secretTypeBase64 = "base64"
+)
+
+// decodeBase64Secret decodes a base64 secret into a new secure buffer.
+func decodeBase64Secret(secret []byte) (*secureBuffer, error) {
+	text := bytes.TrimSpace(secret)
+	decoded, err := newSecureBuffer(base64.StdEncoding.DecodedLen(len(text)))
+	if err != nil {
+		return nil, err
+	}
+	n, err := base64.StdEncoding.Decode(decoded.Bytes(), text)
+	if err != nil || n == 0 {
+		decoded.Destroy()
+		return nil, fmt.Errorf("secret is not valid base64")
+	}
+	defer decoded.Destroy()
+	return newSecureBufferFrom(decoded.Bytes()[:n])
+}
+
+// encodeBase64Secret encodes a restored binary secret into a new secure
+// buffer for output.
+func encodeBase64Secret(secret []byte) (*secureBuffer, error) {
+	encoded, err := newSecureBuffer(base64.StdEncoding.EncodedLen(len(secret)))
+	if err != nil {
+		return nil, err
+	}
+	base64.StdEncoding.Encode(encoded.Bytes(), secret)
+	return encoded, nil
+}
+
+// vaultInit holds the fields of `vault operator init -format=json` that
+// carry key shares. Recovery keys replace unseal keys under auto-unseal.
+type vaultInit struct {
+	UnsealKeysB64         []string `json:"unseal_keys_b64"`
+	UnsealKeysHex         []string `json:"unseal_keys_hex"`
+	UnsealShares          int      `json:"unseal_shares"`
+	UnsealThreshold       int      `json:"unseal_threshold"`
+	RecoveryKeysB64       []string `json:"recovery_keys_b64,omitempty"`
+	RecoveryKeysHex       []string `json:"recovery_keys_hex,omitempty"`
+	RecoveryKeysShares    int      `json:"recovery_keys_shares,omitempty"`
+	RecoveryKeysThreshold int      `json:"recovery_keys_threshold,omitempty"`
+}
+
+// vaultEncoding is the shareEncoding of a single Vault key in base64.
+type vaultEncoding struct{}
+
+func (vaultEncoding) name() string { return shareFormatVault }
+
+func (vaultEncoding) checkSplit(opts splitOptions) error {
+	if opts.pad != "" || opts.compact {
+		return sss.NewError(errUsage, "--pad and --compact cannot be combined with --format vault, Vault keys have no share mode", nil)
+	}
+	return nil
+}
+
+func (vaultEncoding) writeShares(w io.Writer, shares splitShares, opts splitOptions) error {
+	doc, err := vaultInitShares(shares.encoded, opts.threshold)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(doc))
+	return err
+}
+
+// detect accepts base64 that could not be a base32 share typed without its
+// hyphens.
+func (vaultEncoding) detect(text string) bool {
+	if text == "" || strings.ContainsAny(text, "- \t\r\n") {
+		return false
+	}
+	if _, err := base64.StdEncoding.DecodeString(text); err != nil {
+		return false
+	}
+	return strings.ContainsAny(text, "+/=") || strings.ToUpper(text) != text && strings.ToLower(text) != text
+}
+
+func (vaultEncoding) encode(share shareRecord) (string, error) {
+	if share.Mode != shareModeRaw {
+		return "", fmt.Errorf("vault keys cannot carry the %q share mode", share.Mode)
+	}
+	return base64.StdEncoding.EncodeToString(share.Data), nil
+}
+
+// decode names the share after its x coordinate, since Vault keys have no
+// index.
+func (vaultEncoding) decode(text string) (shareRecord, error) {
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "invalid vault key encoding", err)
+	}
+	if len(data) <= sss.ShareOverhead || data[len(data)-1] == 0 {
+		clear(data)
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "invalid vault key", nil)
+	}
+	return shareRecord{Index: int(data[len(data)-1]), Mode: shareModeRaw, Data: data}, nil
+}
+
+// isVaultInit reports whether text looks like a Vault init document.
+func isVaultInit(text []byte) bool {
+	text = bytes.TrimSpace(text)
+	return bytes.HasPrefix(text, []byte("{")) && (bytes.Contains(text, []byte(`"unseal_keys_`)) || bytes.Contains(text, []byte(`"recovery_keys_`)))
+}
+
+// parseVaultInit returns the keys of a Vault init document in base64: the
+// unseal keys, or the recovery keys if it has none.
+func parseVaultInit(text []byte) ([]string, error) {
+	var doc vaultInit
+	if err := json.Unmarshal(text, &doc); err != nil {
+		return nil, sss.NewError(sss.ErrMalformedShare, "invalid vault init JSON", err)
+	}
+	for _, keys := range [][]string{doc.UnsealKeysB64, doc.RecoveryKeysB64} {
+		if len(keys) > 0 {
+			return keys, nil
+		}
+	}
+	for _, keys := range [][]string{doc.UnsealKeysHex, doc.RecoveryKeysHex} {
+		if len(keys) == 0 {
+			continue
+		}
+		b64 := make([]string, len(keys))
+		for i, key := range keys {
+			data, err := hex.DecodeString(key)
+			if err != nil {
+				return nil, sss.NewError(sss.ErrMalformedShare, "invalid vault key encoding", err)
+			}
+			b64[i] = base64.StdEncoding.EncodeToString(data)
+			clear(data)
+		}
+		return b64, nil
+	}
+	return nil, sss.NewError(sss.ErrMalformedShare, "vault init JSON holds no unseal or recovery keys", nil)
+}
+
+// vaultInitShares writes hex shares, as returned by splitPrepared, as the
+// unseal keys of a Vault init document.
+func vaultInitShares(encodedShares string, threshold int) ([]byte, error) {
+	doc := vaultInit{UnsealKeysB64: []string{}, UnsealKeysHex: []string{}, UnsealThreshold: threshold}
+	for _, encodedShare := range strings.Split(encodedShares, ",") {
+		share, err := hexEncoding{}.decode(encodedShare)
+		if err != nil {
+			return nil, err
+		}
+		key, err := vaultEncoding{}.encode(share)
+		if err == nil {
+			doc.UnsealKeysB64 = append(doc.UnsealKeysB64, key)
+			doc.UnsealKeysHex = append(doc.UnsealKeysHex, hex.EncodeToString(share.Data))
+		}
+		clear(share.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	doc.UnsealShares = len(doc.UnsealKeysB64)
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/shamir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultEncodingDetect(t *testing.T) {
+	key := []byte{0x98, 0x7d, 0x0b, 0x5e, 0xff, 0x10, 0x2a, 0x01, 0xc4}
+	base32, err := encodeBase32Share(1, shareModeRaw, []byte("some share bytes"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{base64.StdEncoding.EncodeToString(key), shareFormatVault},
+		{base64.StdEncoding.EncodeToString(key[1:]), shareFormatVault},
+		{hex.EncodeToString(key), shareFormatBase32},
+		{strings.ReplaceAll(base32, "-", ""), shareFormatBase32},
+		{strings.ToLower(strings.ReplaceAll(base32, "-", "")), shareFormatBase32},
+		{"1-" + hex.EncodeToString(key), shareFormatHex},
+	}
+	for _, tt := range tests {
+		e, err := detectShareEncoding(tt.text)
+		require.NoError(t, err, tt.text)
+		require.Equal(t, tt.expected, e.name(), tt.text)
+	}
+
+	share, err := vaultEncoding{}.decode(base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+	require.Equal(t, shareRecord{Index: 0xc4, Mode: shareModeRaw, Data: key}, share)
+	for _, text := range []string{"AA==", "qgA=", "@@"} {
+		_, err := vaultEncoding{}.decode(text)
+		require.Error(t, err, text)
+		require.Equal(t, exitMalformedShare, exitCode(err))
+	}
+	_, err = vaultEncoding{}.encode(shareRecord{Index: 1, Mode: shareModeBIP39, Data: key})
+	require.Error(t, err)
+}
+
+func TestParseVaultInit(t *testing.T) {
+	keys, err := parseVaultInit([]byte(`{"unseal_keys_b64": ["AQI="], "unseal_keys_hex": ["0102"], "unseal_shares": 1, "unseal_threshold": 1}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"AQI="}, keys)
+
+	// Under auto-unseal only recovery keys are printed.
+	keys, err = parseVaultInit([]byte(`{"unseal_keys_b64": [], "unseal_keys_hex": [], "recovery_keys_hex": ["0102", "0304"], "root_token": "hvs.x"}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"AQI=", "AwQ="}, keys)
+
+	for _, text := range []string{`{"unseal_keys_b64": []}`, `{"unseal_keys_b64": 1}`} {
+		require.True(t, isVaultInit([]byte(text)))
+		_, err = parseVaultInit([]byte(text))
+		require.Error(t, err, text)
+		require.Equal(t, exitMalformedShare, exitCode(err))
+	}
+	require.False(t, isVaultInit([]byte(`{"shares": []}`)))
+}
+
+func TestRestoreVaultInit(t *testing.T) {
+	rootKey := bytes.Repeat([]byte{0x5a, 0xc3}, 16)
+	shares, err := shamir.Split(rootKey, 5, 3)
+	require.NoError(t, err)
+	doc := vaultInit{UnsealShares: 5, UnsealThreshold: 3}
+	for _, share := range shares {
+		doc.UnsealKeysB64 = append(doc.UnsealKeysB64, base64.StdEncoding.EncodeToString(share))
+		doc.UnsealKeysHex = append(doc.UnsealKeysHex, hex.EncodeToString(share))
+	}
+	text, err := json.Marshal(doc)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "init.json")
+	require.NoError(t, os.WriteFile(path, text, 0o600))
+
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--type", "base64", "--from-file", path})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, base64.StdEncoding.EncodeToString(rootKey)+"\n", out)
+
+	// Single keys, as kept by each key holder, and hex keys of the document.
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--type", "base64", doc.UnsealKeysB64[4] + "," + doc.UnsealKeysB64[0] + "," + doc.UnsealKeysB64[2]})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, base64.StdEncoding.EncodeToString(rootKey)+"\n", out)
+
+	doc.UnsealKeysB64 = nil
+	text, err = json.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, text, 0o600))
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"restore", "--type", "base64", "--from-file", path})
+	})
+	require.Equal(t, exitOK, code)
+	require.Equal(t, base64.StdEncoding.EncodeToString(rootKey)+"\n", out)
+}
+
+func TestSplitVault(t *testing.T) {
+	rootKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x17}, 32))
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"split", "--format", "vault", "--type", "base64", rootKey, "3", "5"})
+	})
+	require.Equal(t, exitOK, code)
+	var doc vaultInit
+	require.NoError(t, json.Unmarshal([]byte(out), &doc))
+	require.Equal(t, 5, doc.UnsealShares)
+	require.Equal(t, 3, doc.UnsealThreshold)
+	require.Len(t, doc.UnsealKeysB64, 5)
+	require.Len(t, doc.UnsealKeysHex, 5)
+
+	// Vault's own implementation combines the keys.
+	var shares [][]byte
+	for _, key := range doc.UnsealKeysB64[1:4] {
+		share, err := base64.StdEncoding.DecodeString(key)
+		require.NoError(t, err)
+		shares = append(shares, share)
+	}
+	combined, err := shamir.Combine(shares)
+	require.NoError(t, err)
+	require.Equal(t, rootKey, base64.StdEncoding.EncodeToString(combined))
+
+	require.Equal(t, exitUsage, runCLI([]string{"split", "--format", "vault", "--pad", "bucket", "my_secret", "2", "3"}))
+	require.Equal(t, exitInvalidSecret, runCLI([]string{"split", "--type", "base64", "not base64!", "2", "3"}))
+}
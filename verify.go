@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+)
+
+// verify restores a secret only to check it, and never prints or hands it
+// over. It is the scriptable form of restore --verify-only.
+
+type verifyResult struct {
+	jsonHeader
+	SecretType       string   `json:"secret_type"`
+	Checks           []string `json:"checks"`
+	FingerprintMatch *bool    `json:"fingerprint_match,omitempty"`
+}
+
+func verifyCommand(flags *flag.FlagSet) func(args []string) int {
+	sharesArg := flags.String("shares", "", "comma-separated encoded shares")
+	secretType := flags.String("type", secretTypeText, "secret type: text, bip39 or base64")
+	fingerprint := flags.String("fingerprint", "", "fingerprint printed by split --fingerprint")
+	output := flags.String("output", outputText, "output format: text or json")
+
+	return func(args []string) int {
+		out, err := newReporter("verify", *output)
+		if err != nil {
+			return fail("Invalid arguments", err)
+		}
+		if err := bindPositional(flags, 1, "shares"); err != nil {
+			return out.fail("Invalid arguments", err)
+		}
+
+		secret, err := restoreChecked(*sharesArg, *secretType)
+		if err != nil {
+			return out.fail("Verification failed", err)
+		}
+		defer secret.Destroy()
+
+		result := verifyResult{jsonHeader: out.header(), SecretType: *secretType, Checks: []string{"combine"}}
+		first := splitShareList(*sharesArg)[0]
+		if mode, data, err := decodeShare(first); err == nil {
+			clear(data)
+			base, padded := splitShareMode(mode)
+			if padded {
+				result.Checks = append(result.Checks, "padding")
+			}
+			if base == shareModeBIP39 {
+				result.Checks = append(result.Checks, "entropy_checksum")
+			}
+		} else if isCodex32Shares(first) {
+			result.Checks = append(result.Checks, "codex32_checksum")
+		}
+		if *secretType == secretTypeBIP39 {
+			result.Checks = append(result.Checks, "bip39_mnemonic")
+		}
+		if *fingerprint != "" {
+			matches, err := matchFingerprint(secret.Bytes(), *fingerprint)
+			if err != nil {
+				return out.fail("Error checking fingerprint", err)
+			}
+			if !matches {
+				return out.failWith(sss.ErrVerificationFailed, "Fingerprint does not match")
+			}
+			result.Checks = append(result.Checks, "fingerprint")
+			result.FingerprintMatch = &matches
+		}
+
+		if out.json() {
+			out.emit(result)
+			return exitOK
+		}
+		fmt.Printf("Shares passed: %s\n", strings.Join(result.Checks, ", "))
+		if len(result.Checks) == 1 {
+			fmt.Println("Warning: plain text shares combine into something even when they are wrong, use --fingerprint or --type bip39 for a real check")
+		}
+		return exitOK
+	}
+}
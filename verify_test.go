@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	mnemonic := []byte("pen aunt text rotate donate sock shield pottery cloud toy tank sibling parrot oblige agent egg october angle short wolf survey frequent autumn desert")
+	encoded, _, err := splitPrepared(rand.Reader, mnemonic, secretTypeBIP39, true, "", 3, 2)
+	require.NoError(t, err)
+	shares := strings.Split(encoded, ",")
+
+	var code int
+	out := captureStdout(t, func() {
+		code = runCLI([]string{"verify", "--output", "json", "--type", "bip39", strings.Join(shares[:2], ",")})
+	})
+	require.Equal(t, exitOK, code)
+	require.NotContains(t, out, "pen aunt", "verify should never print the secret")
+
+	var result verifyResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.True(t, result.OK)
+	require.Equal(t, []string{"combine", "entropy_checksum", "bip39_mnemonic"}, result.Checks)
+	require.Nil(t, result.FingerprintMatch)
+
+	out = captureStdout(t, func() {
+		code = runCLI([]string{"verify", "--type", "bip39", shares[0]})
+	})
+	require.Equal(t, exitInsufficientShares, code)
+
+	fingerprint, err := secretFingerprint(rand.Reader, []byte("other secret"))
+	require.NoError(t, err)
+	raw, _, err := splitPrepared(rand.Reader, []byte("my_secret"), secretTypeText, false, "", 3, 2)
+	require.NoError(t, err)
+	require.Equal(t, exitVerificationFailed, runCLI([]string{"verify", "--fingerprint", fingerprint, raw}))
+}
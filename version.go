@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3". When
+// it is not, the module version recorded by the Go toolchain is used.
+var version = ""
+
+type buildInfo struct {
+	Version   string
+	Revision  string
+	Time      string
+	Modified  bool
+	GoVersion string
+	Platform  string
+}
+
+func readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.time":
+				info.Time = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "devel"
+	}
+	return info
+}
+
+func printBuildInfo(w io.Writer, info buildInfo) {
+	fmt.Fprintf(w, "shamir %s\n", info.Version)
+	if info.Revision != "" {
+		modified := ""
+		if info.Modified {
+			modified = " (modified)"
+		}
+		fmt.Fprintf(w, "commit: %s%s\n", info.Revision, modified)
+	}
+	if info.Time != "" {
+		fmt.Fprintf(w, "built:  %s\n", info.Time)
+	}
+	fmt.Fprintf(w, "go:     %s %s\n", info.GoVersion, info.Platform)
+}
+
+func versionCommand(flags *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		if len(args) != 0 {
+			return failWith(errUsage, "version takes no arguments")
+		}
+		printBuildInfo(os.Stdout, readBuildInfo())
+		return exitOK
+	}
+}
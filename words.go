@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tofel/shamir/sss"
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+// Words shares spell the record of a base32 share with the English BIP-39
+// wordlist, eleven bits per word, for people who would rather write down
+// words than groups of characters. The first word gives the length of the
+// record in bytes, so the padding of the last word is never mistaken for
+// data:
+//
+//	adapt abandon ability ...
+//
+// Only the first four letters of a word are needed to tell it apart, as with
+// a mnemonic.
+
+const (
+	shareFormatWords = "words"
+
+	wordBits = 11
+)
+
+// wordsEncoding is the shareEncoding of words shares.
+type wordsEncoding struct{}
+
+func (wordsEncoding) name() string { return shareFormatWords }
+
+// detect looks at the first two words only, so that a misspelled word later
+// on is reported by decode.
+func (wordsEncoding) detect(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return false
+	}
+	for _, field := range fields[:2] {
+		if _, ok := lookupShareWord(field); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (wordsEncoding) encode(share shareRecord) (string, error) {
+	data, err := packShareRecord(share)
+	if err != nil {
+		return "", fmt.Errorf("words: %w", err)
+	}
+	defer clear(data)
+	if len(data) >= len(wordlists.English) {
+		return "", fmt.Errorf("words: share %d is too long", share.Index)
+	}
+
+	words := make([]string, 0, 1+(len(data)*8+wordBits-1)/wordBits)
+	words = append(words, wordlists.English[len(data)])
+	var acc, bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= wordBits {
+			bits -= wordBits
+			words = append(words, wordlists.English[acc>>bits&(1<<wordBits-1)])
+		}
+	}
+	if bits > 0 {
+		words = append(words, wordlists.English[acc<<(wordBits-bits)&(1<<wordBits-1)])
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (wordsEncoding) decode(text string) (shareRecord, error) {
+	fields := strings.Fields(text)
+	indices := make([]int, len(fields))
+	for i, field := range fields {
+		index, ok := lookupShareWord(field)
+		if !ok {
+			return shareRecord{}, sss.NewError(sss.ErrMalformedShare, fmt.Sprintf("unknown word %q at position %d", field, i+1), nil)
+		}
+		indices[i] = index
+	}
+	defer clear(indices)
+
+	if len(indices) < 2 {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "words share is too short", nil)
+	}
+	length := indices[0]
+	if (length*8+wordBits-1)/wordBits != len(indices)-1 {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "words share has the wrong number of words, a word may be missing", nil)
+	}
+	data := make([]byte, 0, length)
+	defer clear(data)
+	var acc, bits uint
+	for _, index := range indices[1:] {
+		acc = acc<<wordBits | uint(index)
+		bits += wordBits
+		for bits >= 8 && len(data) < length {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+	if acc&(1<<bits-1) != 0 {
+		return shareRecord{}, sss.NewError(sss.ErrMalformedShare, "words share checksum is invalid, check the share for typos", nil)
+	}
+	return unpackShareRecord(data, shareFormatWords)
+}
+
+// lookupShareWord returns the index of a BIP-39 word, given in full or by
+// its first four letters.
+func lookupShareWord(word string) (int, bool) {
+	word = strings.ToLower(word)
+	if index, ok := bip39WordIndex[word]; ok {
+		return index, true
+	}
+	if len(word) < 4 {
+		return 0, false
+	}
+	index, ok := bip39PrefixIndex[word[:4]]
+	return index, ok && strings.HasPrefix(wordlists.English[index], word)
+}
+
+// bip39PrefixIndex maps the first four letters of every BIP-39 word, which
+// are unique, to its index.
+var bip39PrefixIndex = func() map[string]int {
+	index := make(map[string]int, len(wordlists.English))
+	for i, word := range wordlists.English {
+		index[word[:min(4, len(word))]] = i
+	}
+	return index
+}()
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordsShareRoundTrip(t *testing.T) {
+	// Record lengths cover every amount of padding in the last word.
+	for size := 2; size < 16; size++ {
+		share := shareRecord{Index: size, Mode: shareModeRaw, Data: []byte(strings.Repeat("x", size))}
+		encoded, err := wordsEncoding{}.encode(share)
+		require.NoError(t, err)
+		words := strings.Fields(encoded)
+		require.Len(t, words, 1+((recordHeaderSize+size+recordChecksumSize)*8+10)/11)
+
+		// Words may be abbreviated to four letters and typed in any case.
+		var short []string
+		for _, word := range words {
+			short = append(short, strings.ToUpper(word[:min(4, len(word))]))
+		}
+		for _, input := range []string{encoded, strings.Join(short, "  ")} {
+			decoded, err := wordsEncoding{}.decode(input)
+			require.NoError(t, err)
+			require.Equal(t, share, decoded)
+		}
+	}
+}
+
+func TestDecodeWordsShareErrors(t *testing.T) {
+	encoded, err := wordsEncoding{}.encode(shareRecord{Index: 1, Mode: shareModeBIP39, Data: []byte("some share bytes")})
+	require.NoError(t, err)
+	words := strings.Fields(encoded)
+	swapped := strings.Join(append([]string{words[0], words[2], words[1]}, words[3:]...), " ")
+	replaced := strings.Join(append(words[:len(words)-1:len(words)-1], "zoo"), " ")
+
+	tests := []struct {
+		name  string
+		share string
+		err   string
+	}{
+		{"unknown word", strings.Replace(encoded, words[3], "shamir", 1), `unknown word "shamir" at position 4`},
+		{"missing word", strings.Join(words[:len(words)-1], " "), "wrong number of words"},
+		{"swapped words", swapped, "checksum is invalid"},
+		{"wrong last word", replaced, "checksum is invalid"},
+		{"single word", "abandon", "too short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := wordsEncoding{}.decode(tt.share)
+			require.ErrorContains(t, err, tt.err)
+			require.Equal(t, exitMalformedShare, exitCode(err))
+		})
+	}
+}